@@ -1,11 +1,112 @@
 package events
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/misc"
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
 )
 
-func OnServerDown(cfg *config.Config, srv *config.Server, fails int, restarts int) {
+func OnServerDown(cfg *config.Config, srv *config.Server, fails int, restarts int, reason string, log string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "down", fails, restarts, map[string]string{"REASON": reason, "LOG": misc.FormatLog(log)})
+}
+
+func OnServerUp(cfg *config.Config, srv *config.Server, restarts int, downtime time.Duration, latency time.Duration) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "up", 0, restarts, map[string]string{"DURATION": strconv.Itoa(int(downtime.Seconds())), "LATENCY": strconv.FormatInt(latency.Milliseconds(), 10)})
+}
+
+func OnGaveUp(cfg *config.Config, srv *config.Server, fails int, restarts int, reason string, action string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "gaveup", fails, restarts, map[string]string{"REASON": reason, "ACTION": action})
+}
+
+func OnResourceWarn(cfg *config.Config, srv *config.Server, reason string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "resourcewarn", 0, 0, map[string]string{"REASON": reason})
+}
+
+func OnServerStuck(cfg *config.Config, srv *config.Server, fails int, restarts int, reason string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "stuck", fails, restarts, map[string]string{"REASON": reason})
+}
+
+func OnInstallFail(cfg *config.Config, srv *config.Server, status string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "installfail", 0, 0, map[string]string{"REASON": status})
+}
+
+func OnAutoStart(cfg *config.Config, srv *config.Server, fails int, restarts int) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "autostart", fails, restarts, map[string]string{"REASON": "container found offline"})
+}
+
+func OnRestartSuccess(cfg *config.Config, srv *config.Server, fails int, restarts int, took time.Duration) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "restartsuccess", fails, restarts, map[string]string{"DURATION": strconv.Itoa(int(took.Seconds()))})
+}
+
+func OnBackupFail(cfg *config.Config, srv *config.Server, reason string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "backupfail", 0, 0, map[string]string{"REASON": reason})
+}
+
+func OnRestartFail(cfg *config.Config, srv *config.Server, fails int, restarts int, reason string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "restartfail", fails, restarts, map[string]string{"REASON": reason})
+}
+
+func OnSafeMode(cfg *config.Config, scope string, count int, reason string) {
+	// Aggregated events aren't bound to a single server.
+	srv := config.Server{Name: scope}
+
+	// Handle Misc options.
+	misc.HandleMisc(cfg, &srv, "safemode", 0, 0, map[string]string{"SCOPE": scope, "COUNT": strconv.Itoa(count), "REASON": reason})
+}
+
+func OnSafeRecover(cfg *config.Config, scope string, count int) {
+	// Aggregated events aren't bound to a single server.
+	srv := config.Server{Name: scope}
+
+	// Handle Misc options.
+	misc.HandleMisc(cfg, &srv, "saferecover", 0, 0, map[string]string{"SCOPE": scope, "COUNT": strconv.Itoa(count)})
+}
+
+func OnNodeDown(cfg *config.Config, node string, panel string, servers []string, total int) {
+	// Aggregated events aren't bound to a single server.
+	srv := config.Server{Name: "node " + node}
+
+	// Handle Misc options.
+	misc.HandleMisc(cfg, &srv, "nodedown", 0, 0, map[string]string{"NODE": node, "PANEL": panel, "SERVERS": strings.Join(servers, ", "), "COUNT": strconv.Itoa(len(servers)), "TOTAL": strconv.Itoa(total)})
+}
+
+func OnNodeUp(cfg *config.Config, node string, panel string, servers []string, total int) {
+	// Aggregated events aren't bound to a single server.
+	srv := config.Server{Name: "node " + node}
+
+	// Handle Misc options.
+	misc.HandleMisc(cfg, &srv, "nodeup", 0, 0, map[string]string{"NODE": node, "PANEL": panel, "SERVERS": strings.Join(servers, ", "), "COUNT": strconv.Itoa(len(servers)), "TOTAL": strconv.Itoa(total)})
+}
+
+func OnServerAdded(cfg *config.Config, srv *config.Server) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "serveradd", 0, 0, map[string]string{})
+}
+
+func OnServerRemoved(cfg *config.Config, srv *config.Server) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "serverremove", 0, 0, map[string]string{})
+}
+
+func OnServerChanged(cfg *config.Config, srv *config.Server, changes string) {
+	// Handle Misc options.
+	misc.HandleMisc(cfg, srv, "serverchange", 0, 0, map[string]string{"REASON": changes})
+}
+
+func OnStateChange(cfg *config.Config, srv *config.Server, fails int, restarts int, from string, to string, reason string, took time.Duration) {
 	// Handle Misc options.
-	misc.HandleMisc(cfg, srv, fails, restarts)
+	misc.HandleMisc(cfg, srv, "statechange", fails, restarts, map[string]string{"FROM": from, "TO": to, "REASON": reason, "DURATION": strconv.Itoa(int(took.Seconds()))})
 }
@@ -7,7 +7,31 @@ import (
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
 )
 
-func FormatContents(app string, formatstr *string, fails int, restarts int, srv *config.Server, mentionstr string) {
+// The maximum length of a log tail inside of web hooks (Discord messages are limited to 2000 characters).
+const MaxLogLength = 1000
+
+// Formats a log tail as a code block for web hooks. Older lines are cut if the log is too long. Returns an empty string if there's no log.
+func FormatLog(log string) string {
+	if len(log) < 1 {
+		return ""
+	}
+
+	if len(log) > MaxLogLength {
+		log = log[len(log)-MaxLogLength:]
+
+		// Don't start in the middle of a line.
+		if idx := strings.Index(log, "\n"); idx >= 0 {
+			log = log[idx+1:]
+		}
+	}
+
+	// Make sure the log can't close the code block early.
+	log = strings.ReplaceAll(log, "```", "'''")
+
+	return "\n```\n" + log + "\n```"
+}
+
+func FormatContents(app string, formatstr *string, fails int, restarts int, srv *config.Server, mentionstr string, vars map[string]string) {
 	*formatstr = strings.ReplaceAll(*formatstr, "{IP}", srv.IP)
 	*formatstr = strings.ReplaceAll(*formatstr, "{PORT}", strconv.Itoa(srv.Port))
 	*formatstr = strings.ReplaceAll(*formatstr, "{FAILS}", strconv.Itoa(fails))
@@ -19,4 +43,9 @@ func FormatContents(app string, formatstr *string, fails int, restarts int, srv
 	*formatstr = strings.ReplaceAll(*formatstr, "{RESTARTINT}", strconv.Itoa(srv.RestartInt))
 	*formatstr = strings.ReplaceAll(*formatstr, "{NAME}", srv.Name)
 	*formatstr = strings.ReplaceAll(*formatstr, "{MENTIONS}", mentionstr)
+
+	// Replace event-specific variables.
+	for k, v := range vars {
+		*formatstr = strings.ReplaceAll(*formatstr, "{"+k+"}", v)
+	}
 }
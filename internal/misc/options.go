@@ -9,7 +9,70 @@ import (
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
 )
 
-func HandleMisc(cfg *config.Config, srv *config.Server, fails int, restarts int) {
+// Default web hook contents for each event type.
+var DefContents = map[string]string{
+	"down":           "**SERVER DOWN**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Fail Count** => {FAILS}/{MAXFAILS}\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}\n\nScanning again in *{RESTARTINT}* seconds...{LOG}",
+	"up":             "**SERVER UP**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Downtime** => {DURATION} seconds\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}\n- **Latency** => {LATENCY} ms",
+	"gaveup":         "{MENTIONS} **GAVE UP ON SERVER**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Reason** => {REASON}\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}\n- **Action** => {ACTION}\n\nThe server won't be restarted until it recovers or is reset. Manual intervention is required!",
+	"restartsuccess": "**RESTART SUCCEEDED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Took** => {DURATION} seconds\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}",
+	"backupfail":     "**BACKUP FAILED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Reason** => {REASON}\n\nRestarting without a backup...",
+	"resourcewarn":   "**RESOURCE WARNING**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Rule** => {REASON}",
+	"autostart":      "**SERVER OFFLINE**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}\n\nThe container stopped unexpectedly. Starting it...",
+	"stuck":          "**SERVER STUCK**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Reason** => {REASON}\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}",
+	"installfail":    "**INSTALL FAILED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Status** => {REASON}\n\nWatching is paused until the server is installed.",
+	"restartfail":    "**RESTART FAILED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Reason** => {REASON}\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}",
+	"safemode":       "**SAFE MODE**\n- **Scope** => {SCOPE}\n- **Failing Servers** => {COUNT}\n- **Reason** => {REASON}\n\nRestarts are paused until it recovers.",
+	"saferecover":    "**SAFE MODE ENDED**\n- **Scope** => {SCOPE}\n\nRestarts are resumed.",
+	"nodedown":       "**NODE DOWN**\n- **Node** => {NODE}\n- **Panel** => {PANEL}\n- **Failing Servers** => {COUNT}/{TOTAL}\n- **Servers** => {SERVERS}\n\nRestarts on this node are paused until it recovers.",
+	"serveradd":      "**SERVER ADDED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n\nThe server is now being watched.",
+	"serverremove":   "**SERVER REMOVED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n\nThe server is no longer being watched.",
+	"serverchange":   "**SERVER CHANGED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Changes** => {REASON}",
+	"statechange":    "**STATE CHANGED**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **State** => {FROM} => {TO}\n- **Reason** => {REASON}",
+	"nodeup":         "**NODE RECOVERED**\n- **Node** => {NODE}\n- **Panel** => {PANEL}\n- **Failing Servers** => {COUNT}/{TOTAL}\n\nRestarts are resumed.",
+}
+
+// Events that are executed if no events list is specified (outages, failures, and warnings). Other events (e.g. state and server list changes) are noisy and must be listed explicitly.
+var DefEvents = []string{"down", "up", "gaveup", "restartfail", "stuck", "autostart", "backupfail", "resourcewarn", "installfail", "safemode", "saferecover", "nodedown", "nodeup"}
+
+// Checks whether a misc option should be executed for the event type.
+func WantsEvent(data map[string]interface{}, event string) bool {
+	list, ok := data["events"].([]interface{})
+
+	if !ok {
+		for _, e := range DefEvents {
+			if e == event {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, e := range list {
+		if str, ok := e.(string); ok && str == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks whether any misc option would be executed for the event type (e.g. to skip retrieving data for events nobody receives).
+func WantsAny(cfg *config.Config, event string) bool {
+	for _, v := range cfg.Misc {
+		if v.Type != "webhook" {
+			continue
+		}
+
+		if data, ok := v.Data.(map[string]interface{}); ok && WantsEvent(data, event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func HandleMisc(cfg *config.Config, srv *config.Server, event string, fails int, restarts int, vars map[string]string) {
 	// Look for Misc options.
 	if len(cfg.Misc) > 0 {
 		for i, v := range cfg.Misc {
@@ -20,8 +83,13 @@ func HandleMisc(cfg *config.Config, srv *config.Server, fails int, restarts int)
 
 			// Handle web hooks.
 			if v.Type == "webhook" {
+				// Check if this web hook wants the event.
+				if !WantsEvent(v.Data.(map[string]interface{}), event) {
+					continue
+				}
+
 				// Set defaults.
-				contentpre := "**SERVER DOWN**\n- **Name** => {NAME}\n- **IP** => {IP}:{PORT}\n- **Fail Count** => {FAILS}/{MAXFAILS}\n- **Restart Count** => {RESTARTS}/{MAXRESTARTS}\n\nScanning again in *{RESTARTINT}* seconds..."
+				contentpre := DefContents[event]
 				username := "Pterowatch"
 				avatarurl := ""
 				allowedmentions := AllowMentions{
@@ -44,11 +112,18 @@ func HandleMisc(cfg *config.Config, srv *config.Server, fails int, restarts int)
 
 				url := v.Data.(map[string]interface{})["url"].(string)
 
-				// Look for contents override.
-				if v.Data.(map[string]interface{})["contents"] != nil {
+				// Look for contents override (only applies to server down events).
+				if event == "down" && v.Data.(map[string]interface{})["contents"] != nil {
 					contentpre = v.Data.(map[string]interface{})["contents"].(string)
 				}
 
+				// Look for event-specific contents override.
+				if templates, ok := v.Data.(map[string]interface{})["templates"].(map[string]interface{}); ok {
+					if tmpl, ok := templates[event].(string); ok {
+						contentpre = tmpl
+					}
+				}
+
 				// Look for username override.
 				if v.Data.(map[string]interface{})["username"] != nil {
 					username = v.Data.(map[string]interface{})["username"].(string)
@@ -166,11 +241,11 @@ func HandleMisc(cfg *config.Config, srv *config.Server, fails int, restarts int)
 
 				// Replace variables in strings.
 				contents := contentpre
-				FormatContents(app, &contents, fails, restarts, srv, mentionstr)
+				FormatContents(app, &contents, fails, restarts, srv, mentionstr, vars)
 
 				// Level 3 debug.
 				if cfg.DebugLevel > 2 {
-					fmt.Println("[D3] Loaded web hook with App => " + app + ". URL => " + url + ". Contents => " + contents + ". Username => " + username + ". Avatar URL => " + avatarurl + ". Mentions => Roles:" + strconv.FormatBool(allowedmentions.Roles) + "; Users:" + strconv.FormatBool(allowedmentions.Users) + ".")
+					fmt.Println("[D3] Loaded web hook with Event => " + event + ". App => " + app + ". URL => " + url + ". Contents => " + contents + ". Username => " + username + ". Avatar URL => " + avatarurl + ". Mentions => Roles:" + strconv.FormatBool(allowedmentions.Roles) + "; Users:" + strconv.FormatBool(allowedmentions.Users) + ".")
 				}
 
 				// Submit web hook.
@@ -0,0 +1,44 @@
+package misc
+
+import (
+	"testing"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+func TestWantsEvent(t *testing.T) {
+	// Web hooks without an events list only receive the default events.
+	data := map[string]interface{}{"url": "https://example.com"}
+
+	for _, event := range []string{"down", "up", "gaveup", "restartfail", "stuck", "autostart", "backupfail", "resourcewarn", "installfail", "safemode", "saferecover", "nodedown", "nodeup"} {
+		if !WantsEvent(data, event) {
+			t.Errorf("default web hook doesn't want %s", event)
+		}
+	}
+
+	for _, event := range []string{"restartsuccess", "serveradd", "serverremove", "serverchange", "statechange"} {
+		if WantsEvent(data, event) {
+			t.Errorf("default web hook wants %s", event)
+		}
+	}
+
+	data["events"] = []interface{}{"statechange"}
+
+	if !WantsEvent(data, "statechange") || WantsEvent(data, "down") {
+		t.Error("events list isn't respected")
+	}
+}
+
+func TestWantsAny(t *testing.T) {
+	cfg := &config.Config{}
+
+	if WantsAny(cfg, "down") {
+		t.Error("config without web hooks wants down events")
+	}
+
+	cfg.Misc = []config.Misc{{Type: "webhook", Data: map[string]interface{}{"url": "https://example.com", "events": []interface{}{"nodedown"}}}}
+
+	if WantsAny(cfg, "down") || !WantsAny(cfg, "nodedown") {
+		t.Error("web hook events aren't respected")
+	}
+}
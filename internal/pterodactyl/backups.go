@@ -0,0 +1,223 @@
+package pterodactyl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+	pteroapi "github.com/gamemann/Rust-Auto-Wipe/pkg/pterodactyl"
+)
+
+// The name prefix of backups created by Pterowatch (used to rotate our own backups only).
+const BackupPrefix = "Pterowatch"
+
+// Backup struct from /api/client/servers/xxxx/backups.
+type Backup struct {
+	UUID         string  `json:"uuid"`
+	Name         string  `json:"name"`
+	IsSuccessful bool    `json:"is_successful"`
+	IsLocked     bool    `json:"is_locked"`
+	CreatedAt    string  `json:"created_at"`
+	CompletedAt  *string `json:"completed_at"`
+}
+
+// Backup object from /api/client/servers/xxxx/backups.
+type BackupObj struct {
+	Attributes Backup `json:"attributes"`
+}
+
+// Backup list from /api/client/servers/xxxx/backups.
+type BackupList struct {
+	Data []BackupObj `json:"data"`
+}
+
+// Retrieves the backup limit of the specified server.
+func GetBackupLimit(panel *config.Panel, uid string) (int, error) {
+	details, err := GetDetails(panel, uid)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return details.Attributes.FeatureLimits.Backups, nil
+}
+
+// Retrieves all backups of the specified server.
+func ListBackups(panel *config.Panel, uid string) ([]Backup, error) {
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/backups?per_page=100", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rc != 200 {
+		return nil, errors.New("backup list returned status code " + strconv.Itoa(rc))
+	}
+
+	var list BackupList
+
+	err = json.Unmarshal([]byte(body), &list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Backup{}
+
+	for _, b := range list.Data {
+		backups = append(backups, b.Attributes)
+	}
+
+	return backups, nil
+}
+
+// Retrieves a single backup of the specified server.
+func GetBackup(panel *config.Panel, uid string, backup string) (Backup, error) {
+	var obj BackupObj
+
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/backups/"+backup, nil)
+
+	if err != nil {
+		return obj.Attributes, err
+	}
+
+	if rc != 200 {
+		return obj.Attributes, errors.New("backup returned status code " + strconv.Itoa(rc))
+	}
+
+	err = json.Unmarshal([]byte(body), &obj)
+
+	return obj.Attributes, err
+}
+
+// Creates a backup of the specified server and returns the new backup.
+func CreateBackup(panel *config.Panel, uid string, name string) (Backup, error) {
+	var obj BackupObj
+
+	form_data := make(map[string]interface{})
+	form_data["name"] = name
+
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "POST", "client/servers/"+uid+"/backups", form_data)
+
+	if err != nil {
+		return obj.Attributes, err
+	}
+
+	if rc < 200 || rc > 299 {
+		return obj.Attributes, errors.New("backup creation returned status code " + strconv.Itoa(rc) + " (" + body + ")")
+	}
+
+	err = json.Unmarshal([]byte(body), &obj)
+
+	return obj.Attributes, err
+}
+
+// Deletes a backup of the specified server.
+func DeleteBackup(panel *config.Panel, uid string, backup string) bool {
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "DELETE", "client/servers/"+uid+"/backups/"+backup, nil)
+
+	if err != nil {
+		fmt.Println(err)
+
+		return false
+	}
+
+	if rc < 200 || rc > 299 {
+		fmt.Println("[ERR] Deleting backup " + backup + " for " + uid + " returned status code " + strconv.Itoa(rc) + ".")
+		fmt.Println(body)
+
+		return false
+	}
+
+	return true
+}
+
+// Deletes the oldest backups created by Pterowatch until there's room for a new backup within the server's backup limit.
+func RotateBackups(cfg *config.Config, panel *config.Panel, uid string) error {
+	limit, err := GetBackupLimit(panel, uid)
+
+	if err != nil {
+		return err
+	}
+
+	if limit < 1 {
+		return errors.New("server has no backup slots")
+	}
+
+	backups, err := ListBackups(panel, uid)
+
+	if err != nil {
+		return err
+	}
+
+	// Sort oldest first.
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt < backups[j].CreatedAt
+	})
+
+	count := len(backups)
+
+	for _, b := range backups {
+		if count < limit {
+			break
+		}
+
+		// Only rotate our own backups that aren't locked.
+		if !strings.HasPrefix(b.Name, BackupPrefix) || b.IsLocked {
+			continue
+		}
+
+		if cfg.DebugLevel > 1 {
+			fmt.Println("[D2] Rotating backup " + b.UUID + " (" + b.Name + ") for " + uid + ".")
+		}
+
+		if DeleteBackup(panel, uid, b.UUID) {
+			count--
+		}
+	}
+
+	if count >= limit {
+		return errors.New("backup limit reached (" + strconv.Itoa(limit) + ") and no Pterowatch backups can be rotated")
+	}
+
+	return nil
+}
+
+// Creates a backup of the specified server and waits for it to complete or the timeout (in seconds) to be reached.
+func BackupServer(cfg *config.Config, panel *config.Panel, uid string, timeout int) error {
+	// Make room for the new backup.
+	err := RotateBackups(cfg, panel, uid)
+
+	if err != nil {
+		return err
+	}
+
+	backup, err := CreateBackup(panel, uid, BackupPrefix+" "+time.Now().Format("2006-01-02 15:04:05"))
+
+	if err != nil {
+		return err
+	}
+
+	var b Backup
+
+	completed := Poll(timeout, func() bool {
+		b, err = GetBackup(panel, uid, backup.UUID)
+
+		return err == nil && b.CompletedAt != nil
+	})
+
+	if !completed {
+		return errors.New("backup " + backup.UUID + " did not complete within " + strconv.Itoa(timeout) + " seconds")
+	}
+
+	if !b.IsSuccessful {
+		return errors.New("backup " + backup.UUID + " failed")
+	}
+
+	return nil
+}
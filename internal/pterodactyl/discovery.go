@@ -0,0 +1,352 @@
+package pterodactyl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+	pteroapi "github.com/gamemann/Rust-Auto-Wipe/pkg/pterodactyl"
+)
+
+// Retrieves all servers/containers from each panel with automatic adding enabled and add them to the config.
+func AddServers(cfg *config.Config) bool {
+	for _, panel := range cfg.GetPanels() {
+		if !panel.AddServers {
+			continue
+		}
+
+		if !AddPanelServers(cfg, &panel) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Retrieves all servers/containers from a panel's Pterodactyl API and add them to the config.
+func AddPanelServers(cfg *config.Config, panel *config.Panel) bool {
+	// Retrieve max page count.
+	pagecount := 1
+	maxpages := 1
+	total := 0
+	done := false
+
+	// Make sure the filters are valid before importing anything.
+	err := ValidateFilters(panel, panel.Filters)
+
+	if err != nil {
+		fmt.Println("[ERR] Invalid discovery filter.")
+		fmt.Println(err)
+
+		return false
+	}
+
+	filtered := 0
+
+	// Retrieve the endpoint and token to use depending on the discovery mode.
+	endpoint := "application/servers?include=" + ServerIncludes(panel) + "&page="
+	token := panel.AppToken
+
+	if panel.Discovery == "client" {
+		endpoint = "client?page="
+		token = ClientToken(panel)
+	}
+
+	for done != true {
+		body, _, err := pteroapi.SendAPIRequest(panel.APIURL, token, "GET", endpoint+strconv.Itoa(pagecount), nil)
+
+		if err != nil {
+			fmt.Println(err)
+
+			return false
+		}
+
+		// Create data interface.
+		var dataobj interface{}
+
+		// Parse JSON.
+		err = json.Unmarshal([]byte(string(body)), &dataobj)
+
+		if err != nil {
+			fmt.Println(err)
+
+			return false
+		}
+
+		// Look for object item before anything.
+		if dataobj.(map[string]interface{})["object"] == nil {
+			fmt.Println("[ERR] 'object' item not found when listing all servers.")
+
+			fmt.Println(string(body))
+
+			return false
+		}
+
+		// Retrieve max page count and total count.
+		maxpages = int(dataobj.(map[string]interface{})["meta"].(map[string]interface{})["pagination"].(map[string]interface{})["total_pages"].(float64))
+		total = int(dataobj.(map[string]interface{})["meta"].(map[string]interface{})["pagination"].(map[string]interface{})["total"].(float64))
+
+		// Loop through each data item (server).
+		for _, j := range dataobj.(map[string]interface{})["data"].([]interface{}) {
+			item := j.(map[string]interface{})
+
+			// Make sure we have a server object.
+			if item["object"] == "server" {
+				// Check discovery filters.
+				if !FilterServer(panel.Filters, item["attributes"].(map[string]interface{})) {
+					if cfg.DebugLevel > 2 {
+						fmt.Println("[D3] Server " + item["attributes"].(map[string]interface{})["identifier"].(string) + " filtered out from discovery.")
+					}
+
+					filtered++
+
+					continue
+				}
+
+				sta, ok := ParseServer(cfg, panel, item["attributes"].(map[string]interface{}))
+
+				if !ok {
+					continue
+				}
+
+				// Append to servers slice.
+				cfg.Servers = append(cfg.Servers, sta)
+			}
+		}
+
+		// Check page count.
+		if pagecount >= maxpages {
+			done = true
+
+			break
+		}
+
+		pagecount++
+	}
+
+	// Level 2 debug.
+	if cfg.DebugLevel > 1 {
+		fmt.Println("[D2] Found " + strconv.Itoa(total) + " servers from API (" + strconv.Itoa(maxpages) + " page(s)). Filtered => " + strconv.Itoa(filtered) + ". Panel => " + panel.Name + ". Discovery => " + panel.Discovery + ".")
+	}
+
+	return true
+}
+
+// Builds a server config from a server object's attributes (from either the application or client API). Returns false if the server is invalid.
+func ParseServer(cfg *config.Config, panel *config.Panel, attr map[string]interface{}) (config.Server, bool) {
+	var sta config.Server
+
+	sta.Panel = panel.Name
+
+	// Set UID (in this case, identifier) and default values.
+	sta.ViaAPI = true
+	sta.UID = attr["identifier"].(string)
+	sta.Name = attr["name"].(string)
+
+	sta.Enable = cfg.DefEnable
+	sta.ScanTime = cfg.DefScanTime
+	sta.MaxFails = cfg.DefMaxFails
+	sta.MaxRestarts = cfg.DefMaxRestarts
+	sta.RestartInt = cfg.DefRestartInt
+	sta.ReportOnly = cfg.DefReportOnly
+	sta.A2STimeout = cfg.DefA2STimeout
+	sta.Mentions = cfg.DefMentions
+	sta.RestartMode = cfg.DefRestartMode
+	sta.StopTimeout = cfg.DefStopTimeout
+	sta.StartTimeout = cfg.DefStartTimeout
+	sta.VerifyTimeout = cfg.DefVerifyTimeout
+	sta.Commands = cfg.DefCommands
+	sta.Backup = cfg.DefBackup
+	sta.BackupTimeout = cfg.DefBackupTimeout
+	sta.Schedule = cfg.DefSchedule
+	sta.SchedTimeout = cfg.DefSchedTimeout
+	sta.LogFile = cfg.DefLogFile
+	sta.LogLines = cfg.DefLogLines
+	sta.Rules = cfg.DefRules
+	sta.KeepRunning = cfg.DefKeepRunning
+	sta.StopMarker = cfg.DefStopMarker
+	sta.MaxStarting = cfg.DefMaxStarting
+	sta.MaxStopping = cfg.DefMaxStopping
+	sta.WarmUp = cfg.DefWarmUp
+	sta.WarmUpEarly = cfg.DefWarmUpEarly
+	sta.GiveUpAction = cfg.DefGiveUpAction
+	sta.GiveUpReset = cfg.DefGiveUpReset
+	sta.ResetMarker = cfg.DefResetMarker
+	sta.NotifyInstall = cfg.DefNotifyInstall
+
+	// Check if the server can't be watched right now. The application API includes "suspended" while the client API includes "is_suspended" and "is_transferring".
+	if status, ok := attr["status"].(string); ok {
+		sta.Status = status
+	}
+
+	if suspended, ok := attr["suspended"].(bool); ok && suspended {
+		sta.Status = "suspended"
+	}
+
+	if suspended, ok := attr["is_suspended"].(bool); ok && suspended {
+		sta.Status = "suspended"
+	}
+
+	if transferring, ok := attr["is_transferring"].(bool); ok && transferring {
+		sta.Status = "transferring"
+	}
+
+	// The application API includes the node's ID while the client API includes the node's name (used to correlate failures).
+	if node, ok := attr["node"].(float64); ok {
+		sta.Node = strconv.Itoa(int(node))
+	}
+
+	if node, ok := attr["node"].(string); ok {
+		sta.Node = node
+	}
+
+	if attr["relationships"] == nil {
+		fmt.Println("[ERR] Server has invalid relationships.")
+
+		return sta, false
+	}
+
+	// The application API includes the default allocation's ID with the server.
+	defalloc := -1
+
+	if id, ok := attr["allocation"].(float64); ok {
+		defalloc = int(id)
+	}
+
+	// Retrieve default IP/port along with query and watched allocations.
+	query := false
+
+	for _, i := range attr["relationships"].(map[string]interface{})["allocations"].(map[string]interface{})["data"].([]interface{}) {
+		if i.(map[string]interface{})["object"].(string) != "allocation" {
+			continue
+		}
+
+		alloc := i.(map[string]interface{})["attributes"].(map[string]interface{})
+
+		var addr config.Address
+		addr.IP = alloc["ip"].(string)
+		addr.Port = int(alloc["port"].(float64))
+
+		// Check for tags inside of the allocation's notes or alias.
+		tags := ""
+
+		if notes, ok := alloc["notes"].(string); ok {
+			tags += notes + " "
+		}
+
+		if alias, ok := alloc["alias"].(string); ok {
+			tags += alias + " "
+		}
+
+		if alias, ok := alloc["ip_alias"].(string); ok {
+			tags += alias
+		}
+
+		// A query allocation replaces the default allocation.
+		if len(cfg.QueryTag) > 0 && strings.Contains(tags, cfg.QueryTag) {
+			sta.IP = addr.IP
+			sta.Port = addr.Port
+			query = true
+
+			continue
+		}
+
+		// TCP allocations (e.g. RCON ports) are checked with a TCP connection in addition to the default allocation.
+		if len(cfg.TCPTag) > 0 && strings.Contains(tags, cfg.TCPTag) {
+			addr.Proto = "tcp"
+			sta.Extra = append(sta.Extra, addr)
+
+			continue
+		}
+
+		// Watched allocations are scanned in addition to the default allocation. They're probed with A2S_INFO, so they must be game or query ports.
+		if len(cfg.WatchTag) > 0 && strings.Contains(tags, cfg.WatchTag) {
+			sta.Extra = append(sta.Extra, addr)
+
+			continue
+		}
+
+		if query {
+			continue
+		}
+
+		// The application API includes the default allocation's ID while the client API includes "is_default".
+		if id, ok := alloc["id"].(float64); ok && int(id) == defalloc {
+			sta.IP = addr.IP
+			sta.Port = addr.Port
+		}
+
+		if def, ok := alloc["is_default"].(bool); ok && def {
+			sta.IP = addr.IP
+			sta.Port = addr.Port
+		}
+	}
+
+	// Extra ports are added after overrides since the IP may be overridden.
+	extraports := []config.Address{}
+
+	// Look for overrides.
+	if attr["relationships"].(map[string]interface{})["variables"] != nil && attr["relationships"].(map[string]interface{})["variables"].(map[string]interface{})["data"] != nil {
+		for _, i := range attr["relationships"].(map[string]interface{})["variables"].(map[string]interface{})["data"].([]interface{}) {
+			// The application API uses "server_variable" while the client API uses "egg_variable".
+			if obj := i.(map[string]interface{})["object"].(string); obj != "server_variable" && obj != "egg_variable" {
+				continue
+			}
+
+			vari := i.(map[string]interface{})["attributes"].(map[string]interface{})
+
+			// Check if we have a value.
+			if vari["server_value"] == nil {
+				continue
+			}
+
+			val := vari["server_value"].(string)
+
+			// Override variables should always be at least one byte in length.
+			if len(val) < 1 {
+				continue
+			}
+
+			if _, err := ApplyOverride(&sta, vari["env_variable"].(string), val, &extraports); err != nil {
+				ReportOverride(&sta, vari["env_variable"].(string), val, err)
+			}
+		}
+	}
+
+	// Overrides inside of the server's description take precedence over egg variables.
+	if desc, ok := attr["description"].(string); ok {
+		for _, o := range ParseDescription(desc) {
+			known, err := ApplyOverride(&sta, o.Name, o.Value, &extraports)
+
+			if err != nil {
+				ReportOverride(&sta, o.Name, o.Value, err)
+			}
+
+			if !known && cfg.DebugLevel > 1 {
+				fmt.Println("[D2] Ignoring unknown description override " + o.Name + " for " + sta.UID + " (" + sta.Name + ").")
+			}
+		}
+	}
+
+	// The restart mode and schedule may be overridden in any order, so they're checked once all overrides are applied.
+	if sta.RestartMode == "schedule" && len(sta.Schedule) < 1 {
+		ReportOverride(&sta, "PTEROWATCH_RESTARTMODE", sta.RestartMode, errors.New("no schedule is set"))
+
+		sta.RestartMode = "kill"
+
+		if cfg.DefRestartMode != "schedule" {
+			sta.RestartMode = cfg.DefRestartMode
+		}
+	}
+
+	for _, addr := range extraports {
+		addr.IP = sta.IP
+
+		sta.Extra = append(sta.Extra, addr)
+	}
+
+	return sta, true
+}
@@ -0,0 +1,170 @@
+package pterodactyl
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+	pteroapi "github.com/gamemann/Rust-Auto-Wipe/pkg/pterodactyl"
+)
+
+// The maximum amount of bytes read from the end of a file when tailing it.
+var MaxTailBytes int64 = 64 * 1024
+
+// Signed URL object from /api/client/servers/xxxx/files/download.
+type SignedURL struct {
+	Attributes struct {
+		URL string `json:"url"`
+	} `json:"attributes"`
+}
+
+// Retrieves a signed URL to download a file on the specified server from its node.
+func GetDownloadURL(panel *config.Panel, uid string, file string) (string, error) {
+	var signed SignedURL
+
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/files/download?file="+url.QueryEscape(file), nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	if rc != 200 {
+		return "", errors.New("file download returned status code " + strconv.Itoa(rc))
+	}
+
+	err = json.Unmarshal([]byte(body), &signed)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(signed.Attributes.URL) < 1 {
+		return "", errors.New("file download returned no URL")
+	}
+
+	return signed.Attributes.URL, nil
+}
+
+// Deletes a file (e.g. a marker) on the specified server.
+func DeleteFile(panel *config.Panel, uid string, file string) error {
+	form_data := make(map[string]interface{})
+	form_data["root"] = path.Dir(file)
+	form_data["files"] = []string{path.Base(file)}
+
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "POST", "client/servers/"+uid+"/files/delete", form_data)
+
+	if err != nil {
+		return err
+	}
+
+	if rc < 200 || rc > 299 {
+		return errors.New("file deletion returned status code " + strconv.Itoa(rc) + " (" + body + ")")
+	}
+
+	return nil
+}
+
+// Reads a stream and keeps at most max bytes from its end.
+func ReadEnd(r io.Reader, max int64) ([]byte, error) {
+	buf := []byte{}
+	chunk := make([]byte, 32*1024)
+
+	for {
+		n, err := r.Read(chunk)
+
+		buf = append(buf, chunk[:n]...)
+
+		if int64(len(buf)) > max {
+			buf = buf[int64(len(buf))-max:]
+		}
+
+		if err == io.EOF {
+			return buf, nil
+		}
+
+		if err != nil {
+			return buf, err
+		}
+	}
+}
+
+// Reads up to max bytes from the end of a file on the specified server. The file is downloaded from the node, so files above the panel's edit size limit may be read as well. Returns true if the file was cut.
+func ReadFileEnd(panel *config.Panel, uid string, file string, max int64) (string, bool, error) {
+	link, err := GetDownloadURL(panel, uid, file)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	req, err := http.NewRequest("GET", link, nil)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	// Only request the end of the file. Nodes that don't support ranges send the whole file which is cut while reading.
+	req.Header.Set("Range", "bytes=-"+strconv.FormatInt(max, 10))
+
+	client := &http.Client{Timeout: time.Second * 10}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		data, err := ReadEnd(resp.Body, max)
+
+		if err != nil {
+			return "", false, err
+		}
+
+		// A partial response's range is "bytes start-end/size".
+		cut := int64(len(data)) >= max
+
+		if cr := resp.Header.Get("Content-Range"); resp.StatusCode == http.StatusPartialContent && len(cr) > 0 {
+			cut = !strings.HasPrefix(cr, "bytes 0-")
+		}
+
+		return string(data), cut, nil
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The file is empty.
+		return "", false, nil
+	}
+
+	return "", false, errors.New("file download returned status code " + strconv.Itoa(resp.StatusCode))
+}
+
+// Retrieves the last lines of a file (e.g. a log) on the specified server. At most MaxTailBytes are read from the end of the file.
+func TailFile(panel *config.Panel, uid string, file string, lines int) (string, error) {
+	contents, cut, err := ReadFileEnd(panel, uid, file, MaxTailBytes)
+
+	if err != nil {
+		return "", err
+	}
+
+	all := strings.Split(strings.TrimRight(contents, "\r\n"), "\n")
+
+	// The first line is likely incomplete if the file was cut.
+	if cut && len(all) > 1 {
+		all = all[1:]
+	}
+
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+
+	return strings.Join(all, "\n"), nil
+}
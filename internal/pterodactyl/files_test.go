@@ -0,0 +1,117 @@
+package pterodactyl
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// Builds a log with the specified amount of numbered lines.
+func testLog(lines int) string {
+	var b strings.Builder
+
+	for i := 1; i <= lines; i++ {
+		b.WriteString("line " + strconv.Itoa(i) + "\n")
+	}
+
+	return b.String()
+}
+
+// Serves a signed download URL and the file from the same server. Ranges are only supported if specified.
+func newFileServer(t *testing.T, contents string, ranges bool) (*httptest.Server, *[]string) {
+	requests := []string{}
+
+	var srv *httptest.Server
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+
+		switch r.URL.Path {
+		case "/api/client/servers/1a7ce997/files/download":
+			w.Write([]byte(`{"object":"signed_url","attributes":{"url":"` + srv.URL + `/download/file?token=abc"}}`))
+
+		case "/download/file":
+			if ranges {
+				http.ServeContent(w, r, "latest.log", time.Now(), bytes.NewReader([]byte(contents)))
+
+				return
+			}
+
+			w.Write([]byte(contents))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return srv, &requests
+}
+
+func TestTailFile(t *testing.T) {
+	max := MaxTailBytes
+	MaxTailBytes = 1024
+
+	defer func() {
+		MaxTailBytes = max
+	}()
+
+	for _, ranges := range []bool{true, false} {
+		// The log is larger than the maximum read so only its end is used.
+		srv, requests := newFileServer(t, testLog(5000), ranges)
+
+		panel := &config.Panel{APIURL: srv.URL + "/", Token: "client"}
+
+		tail, err := TailFile(panel, "1a7ce997", "/logs/latest.log", 3)
+
+		srv.Close()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tail != "line 4998\nline 4999\nline 5000" {
+			t.Errorf("ranges %v: unexpected tail %q", ranges, tail)
+		}
+
+		for _, r := range *requests {
+			if strings.Contains(r, "files/contents") {
+				t.Errorf("ranges %v: file was read through the panel", ranges)
+			}
+		}
+	}
+}
+
+func TestTailFileShort(t *testing.T) {
+	srv, _ := newFileServer(t, testLog(2), true)
+	defer srv.Close()
+
+	panel := &config.Panel{APIURL: srv.URL + "/", Token: "client"}
+
+	tail, err := TailFile(panel, "1a7ce997", "/logs/latest.log", 20)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tail != "line 1\nline 2" {
+		t.Errorf("unexpected tail %q", tail)
+	}
+}
+
+func TestReadEnd(t *testing.T) {
+	data, err := ReadEnd(strings.NewReader(strings.Repeat("a", 100000)+"end"), 10)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "aaaaaaaend" {
+		t.Errorf("unexpected data %q", data)
+	}
+}
@@ -0,0 +1,184 @@
+package pterodactyl
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// Server fields used by discovery filters.
+type FilterInfo struct {
+	Node        int
+	Location    int
+	Egg         int
+	Nest        int
+	Owner       int
+	HasIDs      bool
+	ExternalID  string
+	Name        string
+	Description string
+}
+
+// Retrieves the fields used by discovery filters from a server object's attributes. IDs are only available through the application API.
+func GetFilterInfo(attr map[string]interface{}) FilterInfo {
+	var info FilterInfo
+
+	if name, ok := attr["name"].(string); ok {
+		info.Name = name
+	}
+
+	if desc, ok := attr["description"].(string); ok {
+		info.Description = desc
+	}
+
+	if extid, ok := attr["external_id"].(string); ok {
+		info.ExternalID = extid
+	}
+
+	if node, ok := attr["node"].(float64); ok {
+		info.HasIDs = true
+		info.Node = int(node)
+	}
+
+	if egg, ok := attr["egg"].(float64); ok {
+		info.Egg = int(egg)
+	}
+
+	if nest, ok := attr["nest"].(float64); ok {
+		info.Nest = int(nest)
+	}
+
+	if user, ok := attr["user"].(float64); ok {
+		info.Owner = int(user)
+	}
+
+	// The location is retrieved from the included location relationship.
+	if rel, ok := attr["relationships"].(map[string]interface{}); ok {
+		if loc, ok := rel["location"].(map[string]interface{}); ok {
+			if locattr, ok := loc["attributes"].(map[string]interface{}); ok {
+				if id, ok := locattr["id"].(float64); ok {
+					info.Location = int(id)
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// Checks whether a list of IDs contains the ID.
+func ContainsID(list []int, id int) bool {
+	for _, i := range list {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks whether a list of strings contains the string.
+func ContainsStr(list []string, str string) bool {
+	for _, i := range list {
+		if i == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks a server against a filter set. If all is true, every criterion that's set must match. Otherwise, any criterion that's set must match.
+func MatchesSet(set config.FilterSet, info FilterInfo, all bool) bool {
+	results := []bool{}
+
+	// IDs can't match if they're unavailable (e.g. with client discovery).
+	if len(set.Nodes) > 0 {
+		results = append(results, info.HasIDs && ContainsID(set.Nodes, info.Node))
+	}
+
+	if len(set.Locations) > 0 {
+		results = append(results, info.HasIDs && ContainsID(set.Locations, info.Location))
+	}
+
+	if len(set.Eggs) > 0 {
+		results = append(results, info.HasIDs && ContainsID(set.Eggs, info.Egg))
+	}
+
+	if len(set.Nests) > 0 {
+		results = append(results, info.HasIDs && ContainsID(set.Nests, info.Nest))
+	}
+
+	if len(set.Owners) > 0 {
+		results = append(results, info.HasIDs && ContainsID(set.Owners, info.Owner))
+	}
+
+	if len(set.ExternalIDs) > 0 {
+		results = append(results, ContainsStr(set.ExternalIDs, info.ExternalID))
+	}
+
+	if len(set.Name) > 0 {
+		match, _ := regexp.MatchString(set.Name, info.Name)
+		results = append(results, match)
+	}
+
+	if len(set.Description) > 0 {
+		match, _ := regexp.MatchString(set.Description, info.Description)
+		results = append(results, match)
+	}
+
+	// If no criteria are set, include servers and don't exclude any.
+	if len(results) < 1 {
+		return all
+	}
+
+	for _, r := range results {
+		if all && !r {
+			return false
+		}
+
+		if !all && r {
+			return true
+		}
+	}
+
+	return all
+}
+
+// Checks whether a server passes the discovery filters.
+func FilterServer(filters config.Filters, attr map[string]interface{}) bool {
+	info := GetFilterInfo(attr)
+
+	// Every include criterion must match.
+	if !MatchesSet(filters.Include, info, true) {
+		return false
+	}
+
+	// Any exclude criterion excludes the server.
+	if MatchesSet(filters.Exclude, info, false) {
+		return false
+	}
+
+	return true
+}
+
+// Validates the regular expressions of the discovery filters and makes sure the panel supports each criterion.
+func ValidateFilters(panel *config.Panel, filters config.Filters) error {
+	// Pelican doesn't have locations or nests.
+	if IsPelican(panel) {
+		for _, set := range []config.FilterSet{filters.Include, filters.Exclude} {
+			if len(set.Locations) > 0 || len(set.Nests) > 0 {
+				return errors.New("location and nest filters aren't supported by Pelican panels")
+			}
+		}
+	}
+
+	for _, expr := range []string{filters.Include.Name, filters.Include.Description, filters.Exclude.Name, filters.Exclude.Description} {
+		if _, err := regexp.Compile(expr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,506 @@
+package pterodactyl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// A single override (e.g. from an egg variable or the server's description).
+type Override struct {
+	Name  string
+	Value string
+}
+
+// The largest value accepted by overrides in seconds (one week).
+const MaxOverrideSeconds = 604800
+
+// Valid restart modes.
+var RestartModes = []string{"kill", "restart", "stop", "schedule"}
+
+// Valid actions after giving up on a server.
+var GiveUpActions = []string{"none", "stop", "reportonly", "nostart"}
+
+// Matches host names accepted by the IP override.
+var hostRegex = regexp.MustCompile(`^[A-Za-z0-9.\-]+$`)
+
+// Overrides that were already reported as invalid (so each one is only reported once across reloads).
+var reported = make(map[string]bool)
+var reportedLock sync.Mutex
+
+// Parses an integer and makes sure it's within the range specified.
+func ParseRange(val string, min int, max int) (int, error) {
+	num, err := strconv.Atoi(strings.TrimSpace(val))
+
+	if err != nil {
+		return 0, errors.New("'" + val + "' is not a number")
+	}
+
+	if num < min || num > max {
+		return 0, errors.New(strconv.Itoa(num) + " is not between " + strconv.Itoa(min) + " and " + strconv.Itoa(max))
+	}
+
+	return num, nil
+}
+
+// Parses an amount of seconds either as a number (e.g. "30") or a duration (e.g. "30s" or "5m") and makes sure it's within the range specified.
+func ParseSeconds(val string, min int, max int) (int, error) {
+	val = strings.TrimSpace(val)
+
+	if d, err := time.ParseDuration(val); err == nil {
+		if d%time.Second != 0 {
+			return 0, errors.New("'" + val + "' is not a whole amount of seconds")
+		}
+
+		val = strconv.Itoa(int(d / time.Second))
+	}
+
+	return ParseRange(val, min, max)
+}
+
+// Parses a boolean. Accepts true/false, yes/no, on/off and numbers (above 0 is true).
+func ParseBool(val string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "true", "yes", "on":
+		return true, nil
+
+	case "false", "no", "off":
+		return false, nil
+	}
+
+	num, err := strconv.Atoi(strings.TrimSpace(val))
+
+	if err != nil {
+		return false, errors.New("'" + val + "' is not a boolean")
+	}
+
+	return num > 0, nil
+}
+
+// Sets an integer if the value is within the range specified.
+func SetRange(dst *int, val string, min int, max int) error {
+	num, err := ParseRange(val, min, max)
+
+	if err == nil {
+		*dst = num
+	}
+
+	return err
+}
+
+// Sets an amount of seconds if the value is within the range specified.
+func SetSeconds(dst *int, val string, min int, max int) error {
+	num, err := ParseSeconds(val, min, max)
+
+	if err == nil {
+		*dst = num
+	}
+
+	return err
+}
+
+// Sets a boolean if the value is valid.
+func SetBool(dst *bool, val string) error {
+	b, err := ParseBool(val)
+
+	if err == nil {
+		*dst = b
+	}
+
+	return err
+}
+
+// Applies an override to the server. The name is the override's egg variable name (e.g. "PTEROWATCH_SCANTIME"). Extra ports are collected separately since they depend on the server's final IP. Invalid values are rejected and the server's current value is kept. Returns false if the override is unknown along with an error if the value is invalid.
+func ApplyOverride(sta *config.Server, name string, val string, extraports *[]config.Address) (bool, error) {
+	switch name {
+	case "PTEROWATCH_IP":
+		if net.ParseIP(val) == nil && !hostRegex.MatchString(val) {
+			return true, errors.New("'" + val + "' is not a valid IP or host name")
+		}
+
+		sta.IP = val
+
+	case "PTEROWATCH_PORT", "PTEROWATCH_QUERYPORT":
+		return true, SetRange(&sta.Port, val, 1, 65535)
+
+	case "PTEROWATCH_EXTRAPORTS":
+		// Comma-separated ports on the server's IP. Ports with a "/tcp" suffix (e.g. RCON ports) are checked with a TCP connection instead of A2S_INFO requests. All ports must be valid.
+		ports := []config.Address{}
+
+		for _, p := range strings.Split(val, ",") {
+			var addr config.Address
+
+			p = strings.TrimSpace(p)
+
+			if strings.HasSuffix(p, "/tcp") {
+				p = strings.TrimSuffix(p, "/tcp")
+				addr.Proto = "tcp"
+			}
+
+			port, err := ParseRange(p, 1, 65535)
+
+			if err != nil {
+				return true, err
+			}
+
+			addr.Port = port
+			ports = append(ports, addr)
+		}
+
+		*extraports = append(*extraports, ports...)
+
+	case "PTEROWATCH_NOTIFYINSTALL":
+		return true, SetBool(&sta.NotifyInstall, val)
+
+	case "PTEROWATCH_SCANTIME":
+		return true, SetSeconds(&sta.ScanTime, val, 1, MaxOverrideSeconds)
+
+	case "PTEROWATCH_MAXFAILS":
+		return true, SetRange(&sta.MaxFails, val, 1, 100000)
+
+	case "PTEROWATCH_MAXRESTARTS":
+		return true, SetRange(&sta.MaxRestarts, val, 0, 100000)
+
+	case "PTEROWATCH_RESTARTINT":
+		return true, SetSeconds(&sta.RestartInt, val, 0, MaxOverrideSeconds)
+
+	case "PTEROWATCH_A2STIMEOUT":
+		return true, SetSeconds(&sta.A2STimeout, val, 1, 60)
+
+	case "PTEROWATCH_MENTIONS":
+		var mentions interface{}
+
+		if err := json.Unmarshal([]byte(val), &mentions); err != nil {
+			return true, err
+		}
+
+		sta.Mentions = val
+
+	case "PTEROWATCH_RESTARTMODE":
+		if !ContainsStr(RestartModes, val) {
+			return true, errors.New("'" + val + "' is not a valid restart mode")
+		}
+
+		sta.RestartMode = val
+
+	case "PTEROWATCH_STOPTIMEOUT":
+		return true, SetSeconds(&sta.StopTimeout, val, 1, MaxOverrideSeconds)
+
+	case "PTEROWATCH_STARTTIMEOUT":
+		return true, SetSeconds(&sta.StartTimeout, val, 1, MaxOverrideSeconds)
+
+	case "PTEROWATCH_VERIFYTIMEOUT":
+		return true, SetSeconds(&sta.VerifyTimeout, val, 1, MaxOverrideSeconds)
+
+	case "PTEROWATCH_COMMANDS":
+		var cmds []config.Command
+
+		if err := json.Unmarshal([]byte(val), &cmds); err != nil {
+			return true, err
+		}
+
+		for i, cmd := range cmds {
+			if len(cmd.Command) < 1 || cmd.Delay < 0 {
+				return true, errors.New("command #" + strconv.Itoa(i) + " is empty or has a negative delay")
+			}
+		}
+
+		sta.Commands = cmds
+
+	case "PTEROWATCH_BACKUP":
+		return true, SetBool(&sta.Backup, val)
+
+	case "PTEROWATCH_BACKUPTIMEOUT":
+		return true, SetSeconds(&sta.BackupTimeout, val, 1, MaxOverrideSeconds)
+
+	case "PTEROWATCH_SCHEDULE":
+		if len(val) < 1 {
+			return true, errors.New("schedule name is empty")
+		}
+
+		sta.Schedule = val
+
+	case "PTEROWATCH_SCHEDULETIMEOUT":
+		return true, SetSeconds(&sta.SchedTimeout, val, 1, MaxOverrideSeconds)
+
+	case "PTEROWATCH_LOGFILE":
+		sta.LogFile = val
+
+	case "PTEROWATCH_LOGLINES":
+		return true, SetRange(&sta.LogLines, val, 1, 1000)
+
+	case "PTEROWATCH_RULES":
+		var rules []config.ResourceRule
+
+		if err := json.Unmarshal([]byte(val), &rules); err != nil {
+			return true, err
+		}
+
+		if err := config.ValidateRules(rules); err != nil {
+			return true, err
+		}
+
+		sta.Rules = rules
+
+	case "PTEROWATCH_KEEPRUNNING":
+		return true, SetBool(&sta.KeepRunning, val)
+
+	case "PTEROWATCH_STOPMARKER":
+		sta.StopMarker = val
+
+	case "PTEROWATCH_MAXSTARTING":
+		return true, SetSeconds(&sta.MaxStarting, val, 0, MaxOverrideSeconds)
+
+	case "PTEROWATCH_MAXSTOPPING":
+		return true, SetSeconds(&sta.MaxStopping, val, 0, MaxOverrideSeconds)
+
+	case "PTEROWATCH_WARMUP":
+		return true, SetSeconds(&sta.WarmUp, val, 0, MaxOverrideSeconds)
+
+	case "PTEROWATCH_WARMUPEARLY":
+		return true, SetBool(&sta.WarmUpEarly, val)
+
+	case "PTEROWATCH_GIVEUPACTION":
+		if !ContainsStr(GiveUpActions, val) {
+			return true, errors.New("'" + val + "' is not a valid give up action")
+		}
+
+		sta.GiveUpAction = val
+
+	case "PTEROWATCH_GIVEUPRESET":
+		return true, SetSeconds(&sta.GiveUpReset, val, 0, MaxOverrideSeconds)
+
+	case "PTEROWATCH_RESETMARKER":
+		sta.ResetMarker = val
+
+	case "PTEROWATCH_REPORTONLY":
+		return true, SetBool(&sta.ReportOnly, val)
+
+	case "PTEROWATCH_DISABLE":
+		disable, err := ParseBool(val)
+
+		if err != nil {
+			return true, err
+		}
+
+		sta.Enable = !disable
+
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Reports an invalid override for a server. Each invalid value is only reported once.
+func ReportOverride(sta *config.Server, name string, val string, err error) {
+	key := sta.UID + ":" + name + "=" + val
+
+	reportedLock.Lock()
+	defer reportedLock.Unlock()
+
+	if reported[key] {
+		return
+	}
+
+	reported[key] = true
+
+	fmt.Println("[WARN] Ignoring invalid " + name + " override for " + sta.UID + " (" + sta.Name + "). Reason => " + err.Error() + ".")
+}
+
+// The prefix of description lines including overrides (e.g. "pterowatch: scantime=10 maxfails=3").
+const DescPrefix = "pterowatch:"
+
+// Retrieves an override's egg variable name from its key inside of the description (e.g. "scantime" => "PTEROWATCH_SCANTIME").
+func OverrideName(key string) string {
+	return "PTEROWATCH_" + strings.ToUpper(strings.TrimSpace(key))
+}
+
+// Converts a JSON value to an override value. Booleans are converted to 1 or 0 like egg variables and lists/objects are kept as JSON.
+func OverrideValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+
+	case string:
+		return val
+
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+
+	case bool:
+		if val {
+			return "1"
+		}
+
+		return "0"
+	}
+
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// A member of a JSON object.
+type Member struct {
+	Key   string
+	Value json.RawMessage
+}
+
+// Decodes a JSON object's members in the document's order (maps would be iterated in a random order).
+func DecodeMembers(data []byte) ([]Member, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	members := []Member{}
+
+	for dec.More() {
+		tok, err := dec.Token()
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := tok.(string)
+
+		if !ok {
+			return nil, errors.New("expected a JSON object key")
+		}
+
+		var val json.RawMessage
+
+		err = dec.Decode(&val)
+
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, Member{Key: key, Value: val})
+	}
+
+	// Make sure the object is closed.
+	_, err = dec.Token()
+
+	return members, err
+}
+
+// Parses overrides from a fenced JSON block. Blocks tagged "pterowatch" contain the overrides directly while blocks tagged "json" must include them inside of a "pterowatch" object. Overrides are returned in the block's order, so later keys take precedence (e.g. "port" and "queryport").
+func ParseBlock(tag string, block string) []Override {
+	overrides := []Override{}
+
+	members, err := DecodeMembers([]byte(block))
+
+	if err != nil {
+		return overrides
+	}
+
+	if tag == "json" {
+		var inner []Member
+
+		for _, m := range members {
+			if m.Key == "pterowatch" {
+				inner, err = DecodeMembers(m.Value)
+			}
+		}
+
+		if inner == nil || err != nil {
+			return overrides
+		}
+
+		members = inner
+	}
+
+	for _, m := range members {
+		var v interface{}
+
+		if json.Unmarshal(m.Value, &v) != nil {
+			continue
+		}
+
+		val := OverrideValue(v)
+
+		if len(val) < 1 {
+			continue
+		}
+
+		overrides = append(overrides, Override{Name: OverrideName(m.Key), Value: val})
+	}
+
+	return overrides
+}
+
+// Parses overrides from a server's description. Overrides may be specified on lines starting with "pterowatch:" (e.g. "pterowatch: scantime=10 maxfails=3") or inside of fenced JSON blocks.
+func ParseDescription(desc string) []Override {
+	overrides := []Override{}
+
+	inblock := false
+	tag := ""
+	block := []string{}
+
+	for _, line := range strings.Split(strings.ReplaceAll(desc, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// Check for the start or end of a fenced block.
+		if strings.HasPrefix(trimmed, "```") {
+			if !inblock {
+				inblock = true
+				tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "```")))
+				block = []string{}
+
+				continue
+			}
+
+			inblock = false
+
+			if tag == "pterowatch" || tag == "json" {
+				overrides = append(overrides, ParseBlock(tag, strings.Join(block, "\n"))...)
+			}
+
+			continue
+		}
+
+		if inblock {
+			block = append(block, line)
+
+			continue
+		}
+
+		if !strings.HasPrefix(strings.ToLower(trimmed), DescPrefix) {
+			continue
+		}
+
+		for _, field := range strings.Fields(trimmed[len(DescPrefix):]) {
+			kv := strings.SplitN(field, "=", 2)
+
+			if len(kv) != 2 || len(kv[1]) < 1 {
+				continue
+			}
+
+			overrides = append(overrides, Override{Name: OverrideName(kv[0]), Value: kv[1]})
+		}
+	}
+
+	return overrides
+}
@@ -0,0 +1,301 @@
+package pterodactyl
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+func TestParseDescription(t *testing.T) {
+	desc := "My Rust server\r\n" +
+		"pterowatch: scantime=10 maxfails=3 invalid empty=\n" +
+		"```pterowatch\n" +
+		"{\n" +
+		"    \"backup\": true,\n" +
+		"    \"commands\": [{\"command\": \"say Restarting\", \"delay\": 10}],\n" +
+		"    \"port\": 28015,\n" +
+		"    \"queryport\": 28017,\n" +
+		"    \"logfile\": null\n" +
+		"}\n" +
+		"```\n" +
+		"```go\n" +
+		"pterowatch: ignored=1\n" +
+		"```\n" +
+		"  PteroWatch: reportonly=yes\n"
+
+	want := []Override{
+		{Name: "PTEROWATCH_SCANTIME", Value: "10"},
+		{Name: "PTEROWATCH_MAXFAILS", Value: "3"},
+		{Name: "PTEROWATCH_BACKUP", Value: "1"},
+		{Name: "PTEROWATCH_COMMANDS", Value: `[{"command":"say Restarting","delay":10}]`},
+		{Name: "PTEROWATCH_PORT", Value: "28015"},
+		{Name: "PTEROWATCH_QUERYPORT", Value: "28017"},
+		{Name: "PTEROWATCH_REPORTONLY", Value: "yes"},
+	}
+
+	got := ParseDescription(desc)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseBlockOrder(t *testing.T) {
+	// Keys must be applied in the block's order on every parse.
+	for i := 0; i < 50; i++ {
+		got := ParseBlock("pterowatch", `{"queryport": 27016, "port": 27015, "a2stimeout": 2, "maxfails": 4}`)
+
+		want := []Override{
+			{Name: "PTEROWATCH_QUERYPORT", Value: "27016"},
+			{Name: "PTEROWATCH_PORT", Value: "27015"},
+			{Name: "PTEROWATCH_A2STIMEOUT", Value: "2"},
+			{Name: "PTEROWATCH_MAXFAILS", Value: "4"},
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestParseBlockJSON(t *testing.T) {
+	got := ParseBlock("json", `{"other": {"port": 1}, "pterowatch": {"port": 27015, "keeprunning": false}}`)
+
+	want := []Override{
+		{Name: "PTEROWATCH_PORT", Value: "27015"},
+		{Name: "PTEROWATCH_KEEPRUNNING", Value: "0"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// JSON blocks without a pterowatch object and invalid blocks are ignored.
+	for _, block := range []string{`{"port": 27015}`, `{"pterowatch": [1, 2]}`, `{"pterowatch": {"port": 1}`, `[1]`} {
+		if got := ParseBlock("json", block); len(got) > 0 {
+			t.Errorf("block %s => %+v", block, got)
+		}
+	}
+}
+
+func TestApplyExtraPorts(t *testing.T) {
+	sta := config.Server{}
+	extraports := []config.Address{}
+
+	if _, err := ApplyOverride(&sta, "PTEROWATCH_EXTRAPORTS", "28017, 28016/tcp", &extraports); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []config.Address{{Port: 28017}, {Port: 28016, Proto: "tcp"}}
+
+	if !reflect.DeepEqual(extraports, want) {
+		t.Errorf("got %+v, want %+v", extraports, want)
+	}
+
+	// A single invalid port rejects the whole list.
+	for _, val := range []string{"28017,abc", "28016/udp", "0/tcp"} {
+		if _, err := ApplyOverride(&sta, "PTEROWATCH_EXTRAPORTS", val, &extraports); err == nil {
+			t.Errorf("extra ports %q accepted", val)
+		}
+	}
+
+	if len(extraports) != 2 {
+		t.Errorf("invalid extra ports were added: %+v", extraports)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		Val  string
+		Want int
+		Err  bool
+	}{
+		{"5", 5, false},
+		{" 10 ", 10, false},
+		{"1", 1, false},
+		{"0", 0, true},
+		{"11", 0, true},
+		{"-3", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseRange(test.Val, 1, 10)
+
+		if (err != nil) != test.Err || got != test.Want {
+			t.Errorf("ParseRange(%q) = %d, %v; want %d (error %v)", test.Val, got, err, test.Want, test.Err)
+		}
+	}
+}
+
+func TestParseSeconds(t *testing.T) {
+	tests := []struct {
+		Val  string
+		Want int
+		Err  bool
+	}{
+		{"30", 30, false},
+		{"30s", 30, false},
+		{"5m", 300, false},
+		{"1h", 3600, false},
+		{"1.5s", 0, true},
+		{"500ms", 0, true},
+		{"abc", 0, true},
+		{"0", 0, true},
+		{"3601", 0, true},
+		{"2h", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseSeconds(test.Val, 1, 3600)
+
+		if (err != nil) != test.Err || got != test.Want {
+			t.Errorf("ParseSeconds(%q) = %d, %v; want %d (error %v)", test.Val, got, err, test.Want, test.Err)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		Val  string
+		Want bool
+		Err  bool
+	}{
+		{"true", true, false},
+		{"yes", true, false},
+		{"On", true, false},
+		{"1", true, false},
+		{"2", true, false},
+		{"false", false, false},
+		{"no", false, false},
+		{"off", false, false},
+		{"0", false, false},
+		{"garbage", false, true},
+		{"", false, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseBool(test.Val)
+
+		if (err != nil) != test.Err || got != test.Want {
+			t.Errorf("ParseBool(%q) = %v, %v; want %v (error %v)", test.Val, got, err, test.Want, test.Err)
+		}
+	}
+}
+
+func TestApplyOverride(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Val   string
+		Known bool
+		Err   bool
+		Check func(sta config.Server) bool
+	}{
+		{"PTEROWATCH_SCANTIME", "1m", true, false, func(sta config.Server) bool { return sta.ScanTime == 60 }},
+		{"PTEROWATCH_MAXFAILS", "3", true, false, func(sta config.Server) bool { return sta.MaxFails == 3 }},
+		{"PTEROWATCH_PORT", "27016", true, false, func(sta config.Server) bool { return sta.Port == 27016 }},
+		{"PTEROWATCH_IP", "game.example.com", true, false, func(sta config.Server) bool { return sta.IP == "game.example.com" }},
+		{"PTEROWATCH_BACKUP", "yes", true, false, func(sta config.Server) bool { return sta.Backup }},
+		{"PTEROWATCH_RESTARTMODE", "stop", true, false, func(sta config.Server) bool { return sta.RestartMode == "stop" }},
+		{"PTEROWATCH_GIVEUPACTION", "nostart", true, false, func(sta config.Server) bool { return sta.GiveUpAction == "nostart" }},
+		{"PTEROWATCH_UNKNOWN", "1", false, false, nil},
+	}
+
+	for _, test := range tests {
+		sta := config.Server{}
+
+		known, err := ApplyOverride(&sta, test.Name, test.Val, &[]config.Address{})
+
+		if known != test.Known || (err != nil) != test.Err {
+			t.Errorf("%s=%s: known %v, error %v", test.Name, test.Val, known, err)
+
+			continue
+		}
+
+		if test.Check != nil && !test.Check(sta) {
+			t.Errorf("%s=%s wasn't applied: %+v", test.Name, test.Val, sta)
+		}
+	}
+}
+
+func TestApplyOverrideKeepsValue(t *testing.T) {
+	// Invalid values are rejected and the previous value is kept.
+	tests := []struct {
+		Name string
+		Val  string
+	}{
+		{"PTEROWATCH_SCANTIME", "abc"},
+		{"PTEROWATCH_SCANTIME", "1.5s"},
+		{"PTEROWATCH_SCANTIME", "0"},
+		{"PTEROWATCH_MAXFAILS", "-1"},
+		{"PTEROWATCH_PORT", "70000"},
+		{"PTEROWATCH_IP", "not a host"},
+		{"PTEROWATCH_BACKUP", "maybe"},
+		{"PTEROWATCH_RESTARTMODE", "reboot"},
+		{"PTEROWATCH_GIVEUPACTION", "explode"},
+		{"PTEROWATCH_SCHEDULE", ""},
+		{"PTEROWATCH_MENTIONS", "{"},
+		{"PTEROWATCH_RULES", `[{"resource": "mem"}]`},
+	}
+
+	for _, test := range tests {
+		sta := config.Server{IP: "192.0.2.10", Port: 28015, ScanTime: 5, MaxFails: 10, Backup: true, RestartMode: "kill", GiveUpAction: "none", Schedule: "Restart"}
+		prev := sta
+
+		known, err := ApplyOverride(&sta, test.Name, test.Val, &[]config.Address{})
+
+		if !known || err == nil {
+			t.Errorf("%s=%s: known %v, error %v; want an error", test.Name, test.Val, known, err)
+		}
+
+		if !reflect.DeepEqual(sta, prev) {
+			t.Errorf("%s=%s changed the server: %+v", test.Name, test.Val, sta)
+		}
+	}
+}
+
+// Captures everything printed while running the function.
+func captureOutput(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+
+	f()
+
+	os.Stdout = stdout
+	w.Close()
+
+	out, _ := ioutil.ReadAll(r)
+
+	return string(out)
+}
+
+func TestReportOverrideOnce(t *testing.T) {
+	sta := config.Server{UID: "reportonce", Name: "Rust"}
+	err := errors.New("'abc' is not a number")
+
+	out := captureOutput(t, func() {
+		// Servers are parsed again on every scan. Therefore, the same invalid value is only reported once.
+		for i := 0; i < 3; i++ {
+			ReportOverride(&sta, "PTEROWATCH_MAXFAILS", "abc", err)
+		}
+
+		// Another value is reported again.
+		ReportOverride(&sta, "PTEROWATCH_MAXFAILS", "xyz", err)
+	})
+
+	if n := strings.Count(out, "[WARN]"); n != 2 {
+		t.Errorf("got %d warnings, want 2:\n%s", n, out)
+	}
+}
@@ -0,0 +1,305 @@
+package pterodactyl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// Panel types with recorded responses inside of testdata.
+var panelTypes = []string{"pterodactyl", "pelican"}
+
+// A fake panel serving recorded responses. Requests are recorded by method and path (including the query string).
+type fakePanel struct {
+	sync.Mutex
+	Server   *httptest.Server
+	Type     string
+	Requests []string
+	Bodies   []string
+	Routes   map[string]fakeResponse
+}
+
+// A recorded response of the fake panel.
+type fakeResponse struct {
+	Code    int
+	Fixture string
+}
+
+func newFakePanel(t *testing.T, paneltype string, routes map[string]fakeResponse) *fakePanel {
+	fp := &fakePanel{Type: paneltype, Routes: routes}
+
+	fp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		route := r.Method + " " + r.URL.RequestURI()
+
+		fp.Lock()
+		fp.Requests = append(fp.Requests, route)
+		fp.Bodies = append(fp.Bodies, string(body))
+		fp.Unlock()
+
+		res, ok := fp.Routes[route]
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(res.Code)
+
+		if len(res.Fixture) > 0 {
+			w.Write(readFixture(t, paneltype, res.Fixture))
+		}
+	}))
+
+	return fp
+}
+
+func (fp *fakePanel) Panel() *config.Panel {
+	return &config.Panel{Name: fp.Type, Type: fp.Type, APIURL: fp.Server.URL + "/", Token: "client", AppToken: "app"}
+}
+
+func readFixture(t *testing.T, paneltype string, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", paneltype, name))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// Retrieves the first server's attributes from a recorded server list.
+func serverAttributes(t *testing.T, paneltype string) map[string]interface{} {
+	var list map[string]interface{}
+
+	err := json.Unmarshal(readFixture(t, paneltype, "application_servers.json"), &list)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return list["data"].([]interface{})[0].(map[string]interface{})["attributes"].(map[string]interface{})
+}
+
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+
+	return cfg
+}
+
+func TestParseServer(t *testing.T) {
+	for _, paneltype := range panelTypes {
+		t.Run(paneltype, func(t *testing.T) {
+			cfg := testConfig()
+			panel := &config.Panel{Name: "main", Type: paneltype}
+
+			srv, ok := ParseServer(cfg, panel, serverAttributes(t, paneltype))
+
+			if !ok {
+				t.Fatal("server wasn't parsed")
+			}
+
+			if srv.UID != "1a7ce997" || srv.Name != "Rust Main" || srv.Panel != "main" || !srv.ViaAPI {
+				t.Errorf("unexpected identity %q %q %q %v", srv.UID, srv.Name, srv.Panel, srv.ViaAPI)
+			}
+
+			if srv.IP != "192.0.2.10" || srv.Port != 28015 {
+				t.Errorf("unexpected address %s:%d", srv.IP, srv.Port)
+			}
+
+			// Watched allocations are probed with A2S_INFO and TCP allocations (e.g. RCON) with a TCP connection.
+			if len(srv.Extra) != 2 || srv.Extra[0].Port != 28017 || len(srv.Extra[0].Proto) > 0 || srv.Extra[1].Port != 28016 || srv.Extra[1].Proto != "tcp" {
+				t.Errorf("unexpected extra allocations %+v", srv.Extra)
+			}
+
+			if srv.Node != "2" {
+				t.Errorf("unexpected node %q", srv.Node)
+			}
+
+			// Egg variables apply first and description overrides take precedence.
+			if srv.ScanTime != 10 {
+				t.Errorf("scan time %d, want 10 from egg variable", srv.ScanTime)
+			}
+
+			if srv.MaxFails != 5 {
+				t.Errorf("max fails %d, want 5 from description", srv.MaxFails)
+			}
+
+			if srv.RestartMode != "stop" {
+				t.Errorf("restart mode %q, want stop from description", srv.RestartMode)
+			}
+
+			if len(srv.Status) > 0 {
+				t.Errorf("unexpected status %q", srv.Status)
+			}
+		})
+	}
+}
+
+func TestParseServerScheduleWithoutName(t *testing.T) {
+	descs := map[string]string{
+		"pterowatch: restartmode=schedule":                  "kill",
+		"pterowatch: restartmode=schedule schedule=Restart": "schedule",
+		"pterowatch: schedule=Restart restartmode=schedule": "schedule",
+	}
+
+	for desc, want := range descs {
+		cfg := testConfig()
+		attr := serverAttributes(t, "pterodactyl")
+		attr["description"] = desc
+
+		srv, ok := ParseServer(cfg, &config.Panel{Name: "main"}, attr)
+
+		if !ok {
+			t.Fatal("server wasn't parsed")
+		}
+
+		if srv.RestartMode != want {
+			t.Errorf("%q: restart mode %q, want %s", desc, srv.RestartMode, want)
+		}
+	}
+}
+
+func TestGetFilterInfo(t *testing.T) {
+	want := map[string]FilterInfo{
+		"pterodactyl": {Node: 2, Location: 3, Egg: 14, Nest: 4, Owner: 1, HasIDs: true, ExternalID: "rust-main", Name: "Rust Main"},
+		"pelican":     {Node: 2, Location: 0, Egg: 14, Nest: 0, Owner: 1, HasIDs: true, ExternalID: "rust-main", Name: "Rust Main"},
+	}
+
+	for _, paneltype := range panelTypes {
+		t.Run(paneltype, func(t *testing.T) {
+			info := GetFilterInfo(serverAttributes(t, paneltype))
+			info.Description = ""
+
+			if info != want[paneltype] {
+				t.Errorf("got %+v, want %+v", info, want[paneltype])
+			}
+		})
+	}
+}
+
+func TestAddPanelServers(t *testing.T) {
+	includes := map[string]string{
+		"pterodactyl": "allocations,variables,location",
+		"pelican":     "allocations,variables",
+	}
+
+	for _, paneltype := range panelTypes {
+		t.Run(paneltype, func(t *testing.T) {
+			route := "GET /api/application/servers?include=" + includes[paneltype] + "&page=1"
+
+			fp := newFakePanel(t, paneltype, map[string]fakeResponse{
+				route: {Code: 200, Fixture: "application_servers.json"},
+			})
+
+			defer fp.Server.Close()
+
+			cfg := testConfig()
+
+			if !AddPanelServers(cfg, fp.Panel()) {
+				t.Fatalf("discovery failed, requests => %v", fp.Requests)
+			}
+
+			if len(cfg.Servers) != 1 || cfg.Servers[0].UID != "1a7ce997" {
+				t.Errorf("unexpected servers %+v", cfg.Servers)
+			}
+		})
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	filters := config.Filters{Include: config.FilterSet{Locations: []int{3}}}
+
+	if err := ValidateFilters(&config.Panel{Type: "pterodactyl"}, filters); err != nil {
+		t.Errorf("pterodactyl rejected location filter: %v", err)
+	}
+
+	if err := ValidateFilters(&config.Panel{Type: "pelican"}, filters); err == nil {
+		t.Error("pelican accepted location filter")
+	}
+
+	filters = config.Filters{Exclude: config.FilterSet{Nests: []int{4}}}
+
+	if err := ValidateFilters(&config.Panel{Type: "pelican"}, filters); err == nil {
+		t.Error("pelican accepted nest filter")
+	}
+}
+
+func TestGetResources(t *testing.T) {
+	for _, paneltype := range panelTypes {
+		t.Run(paneltype, func(t *testing.T) {
+			fp := newFakePanel(t, paneltype, map[string]fakeResponse{
+				"GET /api/client/servers/1a7ce997/resources": {Code: 200, Fixture: "resources.json"},
+			})
+
+			defer fp.Server.Close()
+
+			res, err := GetResources(fp.Panel(), "1a7ce997")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.State != "running" || len(res.Paused) > 0 {
+				t.Errorf("unexpected state %q (paused %q)", res.State, res.Paused)
+			}
+
+			if res.Resources.Memory != 4294967296 || res.Resources.Uptime != 3600000 || res.Resources.CPU != 87.512 {
+				t.Errorf("unexpected resources %+v", res.Resources)
+			}
+		})
+	}
+}
+
+func TestGetResourcesSuspended(t *testing.T) {
+	for _, paneltype := range panelTypes {
+		t.Run(paneltype, func(t *testing.T) {
+			fp := newFakePanel(t, paneltype, map[string]fakeResponse{
+				"GET /api/client/servers/1a7ce997/resources": {Code: 409, Fixture: "conflict.json"},
+				"GET /api/client/servers/1a7ce997":           {Code: 200, Fixture: "details_suspended.json"},
+			})
+
+			defer fp.Server.Close()
+
+			res, err := GetResources(fp.Panel(), "1a7ce997")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Paused != "suspended" {
+				t.Errorf("paused %q, want suspended", res.Paused)
+			}
+		})
+	}
+}
+
+func TestSendPowerSignal(t *testing.T) {
+	for _, paneltype := range panelTypes {
+		t.Run(paneltype, func(t *testing.T) {
+			fp := newFakePanel(t, paneltype, map[string]fakeResponse{
+				"POST /api/client/servers/1a7ce997/power": {Code: 204},
+			})
+
+			defer fp.Server.Close()
+
+			if !KillServer(fp.Panel(), "1a7ce997") {
+				t.Fatal("kill signal wasn't accepted")
+			}
+
+			if len(fp.Bodies) != 1 || !strings.Contains(fp.Bodies[0], `"signal":"kill"`) {
+				t.Errorf("unexpected request bodies %v", fp.Bodies)
+			}
+		})
+	}
+}
@@ -2,16 +2,77 @@ package pterodactyl
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
 	pteroapi "github.com/gamemann/Rust-Auto-Wipe/pkg/pterodactyl"
 )
 
+// How often to poll a container's state when waiting on a power action.
+var PollInterval = time.Second * 2
+
+// Calls check every poll interval until it returns true or the timeout (in seconds) is reached. Returns true if check succeeded.
+func Poll(timeout int, check func() bool) bool {
+	end := time.Now().Add(time.Duration(timeout) * time.Second)
+
+	for {
+		if check() {
+			return true
+		}
+
+		// Check if we've exceeded the timeout.
+		if time.Now().Add(PollInterval).After(end) {
+			return false
+		}
+
+		time.Sleep(PollInterval)
+	}
+}
+
+// Resources struct from /api/client/servers/xxxx/resources.
+type Resources struct {
+	Memory int64   `json:"memory_bytes"`
+	CPU    float64 `json:"cpu_absolute"`
+	Disk   int64   `json:"disk_bytes"`
+	NetRX  int64   `json:"network_rx_bytes"`
+	NetTX  int64   `json:"network_tx_bytes"`
+	Uptime int64   `json:"uptime"`
+}
+
 // Attributes struct from /api/client/servers/xxxx/resources.
 type Attributes struct {
-	State string `json:"current_state"`
+	State     string    `json:"current_state"`
+	Resources Resources `json:"resources"`
+
+	// Why the server can't be watched right now (e.g. "suspended" or "installing"). Empty if the server may be watched.
+	Paused string `json:"-"`
+}
+
+// Limits struct from /api/client/servers/xxxx (memory and disk in MB, CPU in percent, 0 means unlimited).
+type Limits struct {
+	Memory int64 `json:"memory"`
+	Disk   int64 `json:"disk"`
+	CPU    int64 `json:"cpu"`
+}
+
+// Server details from /api/client/servers/xxxx.
+type ServerDetails struct {
+	Attributes struct {
+		IsSuspended            bool    `json:"is_suspended"`
+		IsInstalling           bool    `json:"is_installing"`
+		IsTransferring         bool    `json:"is_transferring"`
+		IsNodeUnderMaintenance bool    `json:"is_node_under_maintenance"`
+		Status                 *string `json:"status"`
+		Limits                 Limits  `json:"limits"`
+		FeatureLimits          struct {
+			Backups int `json:"backups"`
+		} `json:"feature_limits"`
+	} `json:"attributes"`
 }
 
 // Utilization struct from /api/client/servers/xxxx/resources.
@@ -19,233 +80,145 @@ type Utilization struct {
 	Attributes Attributes `json:"attributes"`
 }
 
-// Retrieves all servers/containers from Pterodactyl API and add them to the config.
-func AddServers(cfg *config.Config) bool {
-	// Retrieve max page count.
-	pagecount := 1
-	maxpages := 1
-	total := 0
-	done := false
+// Checks whether the panel is a Pelican panel (a fork of Pterodactyl with a mostly compatible API).
+func IsPelican(panel *config.Panel) bool {
+	return panel.Type == "pelican"
+}
 
-	for done != true {
-		body, _, err := pteroapi.SendAPIRequest(cfg.APIURL, cfg.AppToken, "GET", "application/servers?page="+strconv.Itoa(pagecount)+"&include=allocations,variables", nil)
+// Retrieves the relationships to include when listing servers from the application API. Pelican doesn't have locations.
+func ServerIncludes(panel *config.Panel) string {
+	if IsPelican(panel) {
+		return "allocations,variables"
+	}
 
-		if err != nil {
-			fmt.Println(err)
+	return "allocations,variables,location"
+}
 
-			return false
-		}
+// Retrieves the token used for client API requests. Falls back to the application token for older configs.
+func ClientToken(panel *config.Panel) string {
+	if len(panel.Token) > 0 {
+		return panel.Token
+	}
 
-		// Create data interface.
-		var dataobj interface{}
+	return panel.AppToken
+}
 
-		// Parse JSON.
-		err = json.Unmarshal([]byte(string(body)), &dataobj)
+// Retrieves the current state and resource utilization of a Pterodactyl server's container.
+func GetResources(panel *config.Panel, uid string) (Attributes, error) {
+	// Create utilization struct.
+	var util Utilization
 
-		if err != nil {
-			fmt.Println(err)
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/resources", nil)
 
-			return false
+	if err != nil {
+		return util.Attributes, err
+	}
+
+	// The panel returns a conflict when the server is suspended, installing, transferring, etc.
+	if rc == 409 {
+		details, err := GetDetails(panel, uid)
+
+		if err != nil {
+			return util.Attributes, err
 		}
 
-		// Look for object item before anything.
-		if dataobj.(map[string]interface{})["object"] == nil {
-			fmt.Println("[ERR] 'object' item not found when listing all servers.")
+		util.Attributes.Paused = PausedReason(details)
 
-			fmt.Println(string(body))
+		return util.Attributes, nil
+	}
 
-			return false
-		}
+	if rc != 200 {
+		return util.Attributes, errors.New("resources returned status code " + strconv.Itoa(rc))
+	}
 
-		// Retrieve max page count and total count.
-		maxpages = int(dataobj.(map[string]interface{})["meta"].(map[string]interface{})["pagination"].(map[string]interface{})["total_pages"].(float64))
-		total = int(dataobj.(map[string]interface{})["meta"].(map[string]interface{})["pagination"].(map[string]interface{})["total"].(float64))
-
-		// Loop through each data item (server).
-		for _, j := range dataobj.(map[string]interface{})["data"].([]interface{}) {
-			item := j.(map[string]interface{})
-
-			// Make sure we have a server object.
-			if item["object"] == "server" {
-				attr := item["attributes"].(map[string]interface{})
-
-				// Build new server structure.
-				var sta config.Server
-
-				// Set UID (in this case, identifier) and default values.
-				sta.ViaAPI = true
-				sta.UID = attr["identifier"].(string)
-				sta.Name = attr["name"].(string)
-
-				sta.Enable = cfg.DefEnable
-				sta.ScanTime = cfg.DefScanTime
-				sta.MaxFails = cfg.DefMaxFails
-				sta.MaxRestarts = cfg.DefMaxRestarts
-				sta.RestartInt = cfg.DefRestartInt
-				sta.ReportOnly = cfg.DefReportOnly
-				sta.A2STimeout = cfg.DefA2STimeout
-				sta.Mentions = cfg.DefMentions
-
-				if attr["relationships"] == nil {
-					fmt.Println("[ERR] Server has invalid relationships.")
-
-					continue
-				}
-
-				// Retrieve default IP/port.
-				for _, i := range attr["relationships"].(map[string]interface{})["allocations"].(map[string]interface{})["data"].([]interface{}) {
-					if i.(map[string]interface{})["object"].(string) != "allocation" {
-						continue
-					}
-
-					alloc := i.(map[string]interface{})["attributes"].(map[string]interface{})
-
-					if alloc["assigned"].(bool) {
-						sta.IP = alloc["ip"].(string)
-						sta.Port = int(alloc["port"].(float64))
-					}
-				}
-
-				// Look for overrides.
-				if attr["relationships"].(map[string]interface{})["variables"].(map[string]interface{})["data"] != nil {
-					for _, i := range attr["relationships"].(map[string]interface{})["variables"].(map[string]interface{})["data"].([]interface{}) {
-						if i.(map[string]interface{})["object"].(string) != "server_variable" {
-							continue
-						}
-
-						vari := i.(map[string]interface{})["attributes"].(map[string]interface{})
-
-						// Check if we have a value.
-						if vari["server_value"] == nil {
-							continue
-						}
-
-						val := vari["server_value"].(string)
-
-						// Override variables should always be at least one byte in length.
-						if len(val) < 1 {
-							continue
-						}
-
-						// Check for IP override.
-						if vari["env_variable"].(string) == "PTEROWATCH_IP" {
-							sta.IP = val
-						}
-
-						// Check for port override.
-						if vari["env_variable"].(string) == "PTEROWATCH_PORT" {
-							sta.Port, _ = strconv.Atoi(val)
-						}
-
-						// Check for scan override.
-						if vari["env_variable"].(string) == "PTEROWATCH_SCANTIME" {
-							sta.ScanTime, _ = strconv.Atoi(val)
-						}
-
-						// Check for max fails override.
-						if vari["env_variable"].(string) == "PTEROWATCH_MAXFAILS" {
-							sta.MaxFails, _ = strconv.Atoi(val)
-						}
-
-						// Check for max restarts override.
-						if vari["env_variable"].(string) == "PTEROWATCH_MAXRESTARTS" {
-							sta.MaxRestarts, _ = strconv.Atoi(val)
-						}
-
-						// Check for restart interval override.
-						if vari["env_variable"].(string) == "PTEROWATCH_RESTARTINT" {
-							sta.RestartInt, _ = strconv.Atoi(val)
-						}
-
-						// Check for A2S_INFO timeout override.
-						if vari["env_variable"].(string) == "PTEROWATCH_A2STIMEOUT" {
-							sta.A2STimeout, _ = strconv.Atoi(val)
-						}
-
-						// Check for mentions override.
-						if vari["env_variable"].(string) == "PTEROWATCH_MENTIONS" {
-							sta.Mentions = val
-						}
-
-						// Check for report only override.
-						if vari["env_variable"].(string) == "PTEROWATCH_REPORTONLY" {
-							reportonly, _ := strconv.Atoi(val)
-
-							if reportonly > 0 {
-								sta.ReportOnly = true
-							} else {
-								sta.ReportOnly = false
-							}
-						}
-
-						// Check for disable override.
-						if vari["env_variable"].(string) == "PTEROWATCH_DISABLE" {
-							disable, _ := strconv.Atoi(val)
-
-							if disable > 0 {
-								sta.Enable = false
-							} else {
-								sta.Enable = true
-							}
-						}
-					}
-				}
-
-				// Append to servers slice.
-				cfg.Servers = append(cfg.Servers, sta)
-			}
-		}
+	// Parse JSON.
+	err = json.Unmarshal([]byte(string(body)), &util)
 
-		// Check page count.
-		if pagecount >= maxpages {
-			done = true
+	return util.Attributes, err
+}
 
-			break
-		}
+// Retrieves the current state of a Pterodactyl server's container (e.g. "running", "starting", "stopping" or "offline").
+func GetState(panel *config.Panel, uid string) (string, error) {
+	attr, err := GetResources(panel, uid)
+
+	if err != nil {
+		return "", err
+	}
 
-		pagecount++
+	return attr.State, nil
+}
+
+// Retrieves why a server can't be watched from its details. Returns an empty string if the server may be watched.
+func PausedReason(details ServerDetails) string {
+	attr := details.Attributes
+
+	if attr.IsSuspended {
+		return "suspended"
 	}
 
-	// Level 2 debug.
-	if cfg.DebugLevel > 1 {
-		fmt.Println("[D2] Found " + strconv.Itoa(total) + " servers from API (" + strconv.Itoa(maxpages) + " page(s)).")
+	if attr.IsTransferring {
+		return "transferring"
 	}
 
-	return true
+	if attr.Status != nil && len(*attr.Status) > 0 {
+		return *attr.Status
+	}
+
+	if attr.IsInstalling {
+		return "installing"
+	}
+
+	if attr.IsNodeUnderMaintenance {
+		return "node_maintenance"
+	}
+
+	return ""
 }
 
-// Checks the status of a Pterodactyl server. Returns true if on and false if off.
-// DOES NOT INCLUDE IN "STARTING" MODE.
-func CheckStatus(cfg *config.Config, uid string) bool {
-	body, _, err := pteroapi.SendAPIRequest(cfg.APIURL, cfg.AppToken, "GET", "client/servers/"+uid+"/resources", nil)
+// Retrieves the details (e.g. limits) of a Pterodactyl server.
+func GetDetails(panel *config.Panel, uid string) (ServerDetails, error) {
+	var details ServerDetails
+
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid, nil)
 
 	if err != nil {
-		fmt.Println(err)
+		return details, err
+	}
 
-		return false
+	if rc != 200 {
+		return details, errors.New("server details returned status code " + strconv.Itoa(rc))
 	}
 
-	// Create utilization struct.
-	var util Utilization
+	err = json.Unmarshal([]byte(body), &details)
 
-	// Parse JSON.
-	json.Unmarshal([]byte(string(body)), &util)
+	return details, err
+}
 
-	// Check if the server's state isn't on. If not, return false.
-	if util.Attributes.State != "running" {
-		return false
-	}
+// Polls the server's container state until it matches one of the states specified or the timeout (in seconds) is reached. Returns true if a matching state was found.
+func WaitForState(panel *config.Panel, uid string, timeout int, states ...string) bool {
+	return Poll(timeout, func() bool {
+		state, err := GetState(panel, uid)
 
-	// Otherwise, return true meaning the container is online.
-	return true
+		if err != nil {
+			return false
+		}
+
+		for _, s := range states {
+			if state == s {
+				return true
+			}
+		}
+
+		return false
+	})
 }
 
-// Kills the specified server.
-func KillServer(cfg *config.Config, uid string) bool {
+// Sends a power signal (start, stop, restart or kill) to the specified server.
+func SendPowerSignal(panel *config.Panel, uid string, signal string) bool {
 	form_data := make(map[string]interface{})
-	form_data["signal"] = "kill"
+	form_data["signal"] = signal
 
-	_, _, err := pteroapi.SendAPIRequest(cfg.APIURL, cfg.AppToken, "POST", "client/servers/"+uid+"/"+"power", form_data)
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "POST", "client/servers/"+uid+"/"+"power", form_data)
 
 	if err != nil {
 		fmt.Println(err)
@@ -253,15 +226,23 @@ func KillServer(cfg *config.Config, uid string) bool {
 		return false
 	}
 
+	// Make sure the panel accepted the power action.
+	if rc < 200 || rc > 299 {
+		fmt.Println("[ERR] Power signal '" + signal + "' for " + uid + " returned status code " + strconv.Itoa(rc) + ".")
+		fmt.Println(body)
+
+		return false
+	}
+
 	return true
 }
 
-// Starts the specified server.
-func StartServer(cfg *config.Config, uid string) bool {
+// Sends a console command to the specified server.
+func SendCommand(panel *config.Panel, uid string, command string) bool {
 	form_data := make(map[string]interface{})
-	form_data["signal"] = "start"
+	form_data["command"] = command
 
-	_, _, err := pteroapi.SendAPIRequest(cfg.APIURL, cfg.AppToken, "POST", "client/servers/"+uid+"/"+"power", form_data)
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "POST", "client/servers/"+uid+"/"+"command", form_data)
 
 	if err != nil {
 		fmt.Println(err)
@@ -269,5 +250,86 @@ func StartServer(cfg *config.Config, uid string) bool {
 		return false
 	}
 
+	// Make sure the panel accepted the command.
+	if rc < 200 || rc > 299 {
+		fmt.Println("[ERR] Command '" + command + "' for " + uid + " returned status code " + strconv.Itoa(rc) + ".")
+		fmt.Println(body)
+
+		return false
+	}
+
 	return true
 }
+
+// Activity log entry from /api/client/servers/xxxx/activity.
+type Activity struct {
+	Attributes struct {
+		Event     string `json:"event"`
+		Timestamp string `json:"timestamp"`
+	} `json:"attributes"`
+}
+
+// Activity log list from /api/client/servers/xxxx/activity.
+type ActivityList struct {
+	Data []Activity `json:"data"`
+}
+
+// Retrieves the latest power action (e.g. "start", "stop", "restart" or "kill") from the server's activity log.
+func GetLastPowerAction(panel *config.Panel, uid string) (string, error) {
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/activity?sort=-timestamp&per_page=50", nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	if rc != 200 {
+		return "", errors.New("activity log returned status code " + strconv.Itoa(rc))
+	}
+
+	var list ActivityList
+
+	err = json.Unmarshal([]byte(body), &list)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, a := range list.Data {
+		if strings.HasPrefix(a.Attributes.Event, "server:power.") {
+			return strings.TrimPrefix(a.Attributes.Event, "server:power."), nil
+		}
+	}
+
+	return "", nil
+}
+
+// Checks whether a file exists on the specified server.
+func FileExists(panel *config.Panel, uid string, file string) (bool, error) {
+	_, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/files/contents?file="+url.QueryEscape(file), nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	return rc == 200, nil
+}
+
+// Kills the specified server.
+func KillServer(panel *config.Panel, uid string) bool {
+	return SendPowerSignal(panel, uid, "kill")
+}
+
+// Starts the specified server.
+func StartServer(panel *config.Panel, uid string) bool {
+	return SendPowerSignal(panel, uid, "start")
+}
+
+// Gracefully stops the specified server.
+func StopServer(panel *config.Panel, uid string) bool {
+	return SendPowerSignal(panel, uid, "stop")
+}
+
+// Restarts the specified server using Pterodactyl's restart signal.
+func RestartServer(panel *config.Panel, uid string) bool {
+	return SendPowerSignal(panel, uid, "restart")
+}
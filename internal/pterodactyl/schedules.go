@@ -0,0 +1,151 @@
+package pterodactyl
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+	pteroapi "github.com/gamemann/Rust-Auto-Wipe/pkg/pterodactyl"
+)
+
+// Returned when the server has no schedule with the specified name.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// Schedule struct from /api/client/servers/xxxx/schedules.
+type Schedule struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	IsActive     bool    `json:"is_active"`
+	IsProcessing bool    `json:"is_processing"`
+	LastRunAt    *string `json:"last_run_at"`
+}
+
+// Schedule object from /api/client/servers/xxxx/schedules.
+type ScheduleObj struct {
+	Attributes Schedule `json:"attributes"`
+}
+
+// Schedule list from /api/client/servers/xxxx/schedules.
+type ScheduleList struct {
+	Data []ScheduleObj `json:"data"`
+}
+
+// Retrieves a schedule's last run time as a string (empty if it never ran).
+func (s Schedule) LastRun() string {
+	if s.LastRunAt == nil {
+		return ""
+	}
+
+	return *s.LastRunAt
+}
+
+// Retrieves all schedules of the specified server.
+func ListSchedules(panel *config.Panel, uid string) ([]Schedule, error) {
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/schedules", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rc != 200 {
+		return nil, errors.New("schedule list returned status code " + strconv.Itoa(rc))
+	}
+
+	var list ScheduleList
+
+	err = json.Unmarshal([]byte(body), &list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := []Schedule{}
+
+	for _, s := range list.Data {
+		schedules = append(schedules, s.Attributes)
+	}
+
+	return schedules, nil
+}
+
+// Retrieves a schedule of the specified server by name.
+func FindSchedule(panel *config.Panel, uid string, name string) (Schedule, error) {
+	schedules, err := ListSchedules(panel, uid)
+
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	for _, s := range schedules {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	return Schedule{}, ErrScheduleNotFound
+}
+
+// Retrieves a single schedule of the specified server.
+func GetSchedule(panel *config.Panel, uid string, id int) (Schedule, error) {
+	var obj ScheduleObj
+
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "GET", "client/servers/"+uid+"/schedules/"+strconv.Itoa(id), nil)
+
+	if err != nil {
+		return obj.Attributes, err
+	}
+
+	if rc != 200 {
+		return obj.Attributes, errors.New("schedule returned status code " + strconv.Itoa(rc))
+	}
+
+	err = json.Unmarshal([]byte(body), &obj)
+
+	return obj.Attributes, err
+}
+
+// Triggers a schedule of the specified server.
+func ExecuteSchedule(panel *config.Panel, uid string, id int) error {
+	body, rc, err := pteroapi.SendAPIRequest(panel.APIURL, ClientToken(panel), "POST", "client/servers/"+uid+"/schedules/"+strconv.Itoa(id)+"/execute", nil)
+
+	if err != nil {
+		return err
+	}
+
+	if rc < 200 || rc > 299 {
+		return errors.New("schedule execution returned status code " + strconv.Itoa(rc) + " (" + body + ")")
+	}
+
+	return nil
+}
+
+// Triggers the schedule with the specified name and waits for it to finish or the timeout (in seconds) to be reached. Returns true along with the error if the schedule was triggered.
+func RunSchedule(panel *config.Panel, uid string, name string, timeout int) (bool, error) {
+	sched, err := FindSchedule(panel, uid, name)
+
+	if err != nil {
+		return false, err
+	}
+
+	// The panel updates the last run time once the schedule's last task finished.
+	lastrun := sched.LastRun()
+
+	err = ExecuteSchedule(panel, uid, sched.ID)
+
+	if err != nil {
+		return false, err
+	}
+
+	finished := Poll(timeout, func() bool {
+		s, err := GetSchedule(panel, uid, sched.ID)
+
+		return err == nil && !s.IsProcessing && s.LastRun() != lastrun
+	})
+
+	if !finished {
+		return true, errors.New("schedule '" + name + "' did not finish within " + strconv.Itoa(timeout) + " seconds")
+	}
+
+	return true, nil
+}
@@ -43,6 +43,19 @@ func SendRequest(conn *net.UDPConn) {
 	conn.Write(query)
 }
 
+// Checks whether a TCP connection to the host and port can be established within the timeout (in seconds). Used for ports that don't answer A2S_INFO requests (e.g. RCON).
+func CheckTCP(host string, port int, timeout int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), time.Second*time.Duration(timeout))
+
+	if err != nil {
+		return false
+	}
+
+	conn.Close()
+
+	return true
+}
+
 // Checks for A2S_INFO response. Returns true if it receives a response. Returns false otherwise.
 func CheckResponse(conn *net.UDPConn, srv config.Server) bool {
 	buffer := make([]byte, 1024)
@@ -0,0 +1,26 @@
+package query
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCheckTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	if !CheckTCP("127.0.0.1", port, 1) {
+		t.Error("open TCP port isn't reachable")
+	}
+
+	ln.Close()
+
+	if CheckTCP("127.0.0.1", port, 1) {
+		t.Error("closed TCP port is reachable")
+	}
+}
@@ -0,0 +1,113 @@
+package servers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/events"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/pterodactyl"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// Gives up on a server that reached its max restarts. The gave up event and the server's give up action are only executed once until the server is reset.
+func GiveUp(cfg *config.Config, panel *config.Panel, srv *config.Server, stats *Stats, reason string) {
+	if !stats.GaveUp.IsZero() {
+		stats.SetState(cfg, srv, StateGaveUp, reason)
+
+		return
+	}
+
+	stats.GaveUp = time.Now()
+
+	// Giving up reports the outage (e.g. with maxrestarts set to 0, no down event is fired before). Therefore, the server's recovery is reported as well.
+	stats.MarkDown()
+
+	action := srv.GiveUpAction
+
+	if action == "" {
+		action = "none"
+	}
+
+	if cfg.DebugLevel > 0 {
+		fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Giving up on server. Reason => " + reason + ". Action => " + action + ". Restart Count => " + strconv.Itoa(stats.Restarts) + " (" + srv.Name + ").")
+	}
+
+	stats.SetState(cfg, srv, StateGaveUp, reason)
+
+	events.OnGaveUp(cfg, srv, stats.Fails, stats.Restarts, reason, action)
+
+	switch action {
+	case "stop":
+		// Stop the server so it doesn't keep crashing (this counts as an intentional stop for keep running). It's started again once the server is reset.
+		if !pterodactyl.StopServer(panel, srv.UID) {
+			fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to stop server after giving up (" + srv.Name + ").")
+		} else {
+			stats.Stopped = true
+		}
+
+	case "nostart":
+		// Don't start the server if it's found offline until it recovers or is reset manually.
+		stats.NoStart = true
+	}
+}
+
+// Resets a server we gave up on so it's restarted again. The give up action is undone (a stopped server is started and auto-start is enabled again).
+func (st *Stats) ResetGiveUp(cfg *config.Config, panel *config.Panel, srv *config.Server, reason string) {
+	if cfg.DebugLevel > 0 {
+		fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Resetting server. Reason => " + reason + " (" + srv.Name + ").")
+	}
+
+	st.Fails = 0
+	st.Restarts = 0
+	st.NextScan = 0
+	st.GaveUp = time.Time{}
+	st.NoStart = false
+	st.NotSent = 0
+
+	// Start the server again if we stopped it when giving up (unless its panel or node is in safe mode).
+	if st.Stopped {
+		if panel == nil || InSafeMode(panel, srv) {
+			fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Can't start server after resetting it (" + srv.Name + ").")
+		} else if !pterodactyl.StartServer(panel, srv.UID) {
+			fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to start server after resetting it (" + srv.Name + ").")
+		}
+
+		st.Stopped = false
+	}
+
+	st.SetState(cfg, srv, StateUnknown, reason)
+}
+
+// Checks whether the server's reset marker file exists and deletes it so the server is only reset once.
+func FoundResetMarker(cfg *config.Config, panel *config.Panel, srv *config.Server) bool {
+	if len(srv.ResetMarker) < 1 {
+		return false
+	}
+
+	exists, err := pterodactyl.FileExists(panel, srv.UID, srv.ResetMarker)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	if !exists {
+		return false
+	}
+
+	if cfg.DebugLevel > 2 {
+		fmt.Println("[D3][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Found reset marker " + srv.ResetMarker + " (" + srv.Name + ").")
+	}
+
+	// Don't reset the server if the marker stays (it would be reset each time we give up on it).
+	err = pterodactyl.DeleteFile(panel, srv.UID, srv.ResetMarker)
+
+	if err != nil {
+		fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to delete reset marker " + srv.ResetMarker + " (" + srv.Name + ").")
+		fmt.Println(err)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,210 @@
+package servers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// A fake panel accepting power signals and serving a reset marker. Requests are recorded with their bodies.
+type markerPanel struct {
+	sync.Mutex
+	Server   *httptest.Server
+	Marker   bool
+	Requests []string
+}
+
+func newMarkerPanel() *markerPanel {
+	mp := &markerPanel{}
+
+	mp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		mp.Lock()
+		defer mp.Unlock()
+
+		mp.Requests = append(mp.Requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/power"):
+			w.WriteHeader(http.StatusNoContent)
+
+		case strings.HasSuffix(r.URL.Path, "/files/contents") && mp.Marker:
+			w.Write([]byte("reset"))
+
+		case strings.HasSuffix(r.URL.Path, "/files/delete"):
+			mp.Marker = false
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return mp
+}
+
+func (mp *markerPanel) Panel() *config.Panel {
+	return &config.Panel{Name: "test", APIURL: mp.Server.URL + "/", Token: "client"}
+}
+
+// Retrieves whether a request containing all parts was sent.
+func (mp *markerPanel) Sent(parts ...string) bool {
+	mp.Lock()
+	defer mp.Unlock()
+
+	for _, req := range mp.Requests {
+		found := true
+
+		for _, part := range parts {
+			if !strings.Contains(req, part) {
+				found = false
+			}
+		}
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestResetGiveUpUndoesAction(t *testing.T) {
+	mp := newMarkerPanel()
+	defer mp.Server.Close()
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.SafeMode = false
+
+	// Stopped servers are started again on timed resets.
+	srv := testServer("stopped", 27015)
+	srv.GiveUpAction = "stop"
+
+	stats := Stats{}
+
+	GiveUp(cfg, mp.Panel(), &srv, &stats, "max restarts reached")
+
+	if !stats.Stopped || !mp.Sent("/power", `"signal":"stop"`) {
+		t.Fatal("give up action didn't stop the server")
+	}
+
+	stats.ResetGiveUp(cfg, mp.Panel(), &srv, "give up reset after 60 seconds")
+
+	if stats.Stopped || !mp.Sent("/power", `"signal":"start"`) {
+		t.Error("reset didn't start the stopped server")
+	}
+
+	if !stats.GaveUp.IsZero() || stats.State != StateUnknown {
+		t.Errorf("server wasn't reset: %+v", stats)
+	}
+
+	// Auto-start is enabled again on timed resets.
+	srv = testServer("nostart", 27015)
+	srv.GiveUpAction = "nostart"
+
+	stats = Stats{}
+
+	GiveUp(cfg, mp.Panel(), &srv, &stats, "max restarts reached")
+
+	if !stats.NoStart {
+		t.Fatal("give up action didn't disable auto-start")
+	}
+
+	stats.ResetGiveUp(cfg, mp.Panel(), &srv, "give up reset after 60 seconds")
+
+	if stats.NoStart {
+		t.Error("reset didn't enable auto-start again")
+	}
+}
+
+func TestFoundResetMarker(t *testing.T) {
+	mp := newMarkerPanel()
+	defer mp.Server.Close()
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+
+	srv := testServer("marked", 27015)
+
+	// Servers without a reset marker never check for it.
+	if FoundResetMarker(cfg, mp.Panel(), &srv) || len(mp.Requests) > 0 {
+		t.Fatal("server without reset marker checked for it")
+	}
+
+	srv.ResetMarker = "/.pterowatch-reset"
+
+	if FoundResetMarker(cfg, mp.Panel(), &srv) {
+		t.Fatal("missing reset marker was found")
+	}
+
+	mp.Lock()
+	mp.Marker = true
+	mp.Unlock()
+
+	if !FoundResetMarker(cfg, mp.Panel(), &srv) {
+		t.Fatal("reset marker wasn't found")
+	}
+
+	if !mp.Sent("/files/delete", `"root":"/"`, `".pterowatch-reset"`) {
+		t.Error("reset marker wasn't deleted")
+	}
+
+	// The marker only resets the server once.
+	if FoundResetMarker(cfg, mp.Panel(), &srv) {
+		t.Error("deleted reset marker was found again")
+	}
+}
+
+func TestReportDownMarksOutage(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+
+	srv := testServer("down", 27015)
+	stats := Stats{}
+
+	ReportDown(cfg, &config.Panel{Name: "test"}, &srv, &stats, "query timeout")
+
+	if stats.DownSince.IsZero() {
+		t.Fatal("down event didn't mark the outage")
+	}
+
+	// Repeated reports (e.g. the reportonly give up action) keep the outage's start.
+	since := stats.DownSince
+
+	time.Sleep(time.Millisecond)
+
+	ReportDown(cfg, &config.Panel{Name: "test"}, &srv, &stats, "query timeout")
+
+	if !stats.DownSince.Equal(since) {
+		t.Errorf("outage start moved from %s to %s", since, stats.DownSince)
+	}
+}
+
+func TestGiveUpMarksOutage(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+
+	// Servers with maxrestarts set to 0 give up without a down event.
+	srv := testServer("down", 27015)
+	srv.MaxRestarts = 0
+
+	stats := Stats{}
+
+	GiveUp(cfg, &config.Panel{Name: "test"}, &srv, &stats, "max restarts reached")
+
+	if stats.GaveUp.IsZero() || stats.State != StateGaveUp {
+		t.Fatalf("server didn't give up: %+v", stats)
+	}
+
+	if stats.DownSince.IsZero() {
+		t.Error("giving up didn't mark the outage")
+	}
+}
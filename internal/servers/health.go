@@ -0,0 +1,264 @@
+package servers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/events"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// A failing server within a scope.
+type Failure struct {
+	Name string
+	Time time.Time
+}
+
+// Health of a panel or node (scope). Probed holds the servers that are currently being probed within a node. Answered and FailingSince track when the panel last answered an API request for a server within a node and since when requests within the node fail.
+type HealthState struct {
+	Failing      map[string]Failure
+	Probed       map[string]bool
+	Safe         bool
+	Answered     time.Time
+	FailingSince time.Time
+}
+
+// Tracks API errors per panel and query failures per node to detect outages.
+type HealthTracker struct {
+	sync.Mutex
+	Scopes map[string]*HealthState
+}
+
+var health = HealthTracker{Scopes: make(map[string]*HealthState)}
+
+// Retrieves the scope of a panel.
+func PanelScope(panel *config.Panel) string {
+	return "panel " + panel.Name
+}
+
+// Retrieves the scope of the server's node. Returns an empty string if the node is unknown.
+func NodeScope(panel *config.Panel, srv *config.Server) string {
+	if len(srv.Node) < 1 {
+		return ""
+	}
+
+	return "node " + srv.Node + " on panel " + panel.Name
+}
+
+// Records whether a server failed within a scope and enters or leaves safe mode based off of the amount of servers that failed within the safe window. Returns whether the scope entered or left safe mode along with the names of the failing servers.
+func (h *HealthTracker) Report(cfg *config.Config, scope string, srv *config.Server, failed bool, threshold int) (bool, bool, []string) {
+	if !cfg.SafeMode || len(scope) < 1 || threshold < 1 {
+		return false, false, nil
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	st := h.Scope(scope)
+
+	if failed {
+		st.Failing[srv.UID] = Failure{Name: srv.Name, Time: time.Now()}
+	} else {
+		delete(st.Failing, srv.UID)
+	}
+
+	// Expire failures outside of the window (e.g. servers that were removed).
+	names := []string{}
+
+	for k, f := range st.Failing {
+		if time.Since(f.Time) > time.Duration(cfg.SafeWindow)*time.Second {
+			delete(st.Failing, k)
+
+			continue
+		}
+
+		names = append(names, f.Name)
+	}
+
+	sort.Strings(names)
+
+	enter := !st.Safe && len(names) >= threshold
+	leave := st.Safe && len(names) < threshold
+
+	if enter {
+		st.Safe = true
+	}
+
+	if leave {
+		st.Safe = false
+	}
+
+	return enter, leave, names
+}
+
+// Retrieves a scope's health and creates it if needed. The tracker must be locked.
+func (h *HealthTracker) Scope(scope string) *HealthState {
+	st, ok := h.Scopes[scope]
+
+	if !ok {
+		st = &HealthState{Failing: make(map[string]Failure), Probed: make(map[string]bool)}
+		h.Scopes[scope] = st
+	}
+
+	return st
+}
+
+// Records whether a server within a scope is being probed.
+func (h *HealthTracker) SetProbed(scope string, srv *config.Server, probed bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	st := h.Scope(scope)
+
+	if probed {
+		st.Probed[srv.UID] = true
+	} else {
+		delete(st.Probed, srv.UID)
+	}
+}
+
+// Counts the servers within a scope that are being probed.
+func (h *HealthTracker) CountProbed(scope string) int {
+	h.Lock()
+	defer h.Unlock()
+
+	return len(h.Scope(scope).Probed)
+}
+
+// Records an API result of a server within a node. Returns true if a failure is isolated to the node. That's the case if the panel answered requests for servers on other nodes of the panel since the node started failing. Otherwise, the panel itself may be down.
+func (h *HealthTracker) ReportNodeAPI(panel *config.Panel, scope string, failed bool) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	st := h.Scope(scope)
+
+	if !failed {
+		st.Answered = time.Now()
+		st.FailingSince = time.Time{}
+
+		return false
+	}
+
+	if st.FailingSince.IsZero() {
+		st.FailingSince = time.Now()
+	}
+
+	suffix := " on panel " + panel.Name
+
+	for name, other := range h.Scopes {
+		if name != scope && strings.HasSuffix(name, suffix) && other.Answered.After(st.FailingSince) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks whether a scope is in safe mode.
+func (h *HealthTracker) IsSafe(scope string) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	st, ok := h.Scopes[scope]
+
+	return ok && st.Safe
+}
+
+// Records whether a server is being probed (its container is running and it may be watched). Only probed servers count towards their node's servers since paused or stopped servers never report.
+func SetProbed(panel *config.Panel, srv *config.Server, probed bool) {
+	scope := NodeScope(panel, srv)
+
+	if len(scope) < 1 {
+		return
+	}
+
+	health.SetProbed(scope, srv, probed)
+}
+
+// Records a server's API result and sends one alert for the whole panel if it enters or leaves safe mode.
+func ReportAPI(cfg *config.Config, panel *config.Panel, srv *config.Server, failed bool) {
+	scope := PanelScope(panel)
+
+	enter, leave, names := health.Report(cfg, scope, srv, failed, cfg.SafeAPIErrors)
+
+	if enter {
+		if cfg.DebugLevel > 0 {
+			fmt.Println("[D1] Entering safe mode for " + scope + ". Failing servers => " + strings.Join(names, ", ") + ".")
+		}
+
+		events.OnSafeMode(cfg, scope, len(names), "panel API errors")
+	}
+
+	if leave {
+		if cfg.DebugLevel > 0 {
+			fmt.Println("[D1] Leaving safe mode for " + scope + ".")
+		}
+
+		events.OnSafeRecover(cfg, scope, len(names))
+	}
+}
+
+// Records a server's resources API result. Failures count against the panel. They only count against the server's node if the panel isn't in safe mode and answers requests for servers on other nodes (the panel can't retrieve a server's resources if the node's Wings daemon is unreachable). Therefore, a panel outage isn't mistaken for node outages.
+func ReportResources(cfg *config.Config, panel *config.Panel, srv *config.Server, failed bool) {
+	ReportAPI(cfg, panel, srv, failed)
+
+	scope := NodeScope(panel, srv)
+
+	if len(scope) < 1 {
+		return
+	}
+
+	isolated := health.ReportNodeAPI(panel, scope, failed)
+
+	if failed && isolated && !health.IsSafe(PanelScope(panel)) {
+		ReportQuery(cfg, panel, srv, true)
+	}
+}
+
+// Records a server's query result (or a failed API request isolated to the server's node) and sends one node down alert with the affected servers if failures on the server's node are correlated.
+func ReportQuery(cfg *config.Config, panel *config.Panel, srv *config.Server, failed bool) {
+	scope := NodeScope(panel, srv)
+
+	if len(scope) < 1 {
+		return
+	}
+
+	// Nodes with fewer probed servers than the threshold are down once all of them fail. A single server isn't a correlation.
+	threshold := cfg.SafeNodeFails
+	total := health.CountProbed(scope)
+
+	if total < threshold {
+		threshold = total
+	}
+
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	enter, leave, names := health.Report(cfg, scope, srv, failed, threshold)
+
+	if enter {
+		if cfg.DebugLevel > 0 {
+			fmt.Println("[D1] Detected " + scope + " as down. Failing servers => " + strings.Join(names, ", ") + " (" + strconv.Itoa(len(names)) + "/" + strconv.Itoa(total) + ").")
+		}
+
+		events.OnNodeDown(cfg, srv.Node, panel.Name, names, total)
+	}
+
+	if leave {
+		if cfg.DebugLevel > 0 {
+			fmt.Println("[D1] Detected " + scope + " as up.")
+		}
+
+		events.OnNodeUp(cfg, srv.Node, panel.Name, names, total)
+	}
+}
+
+// Checks whether restarts are paused for the server because its panel or node is in safe mode.
+func InSafeMode(panel *config.Panel, srv *config.Server) bool {
+	return health.IsSafe(PanelScope(panel)) || health.IsSafe(NodeScope(panel, srv))
+}
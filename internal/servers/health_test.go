@@ -0,0 +1,103 @@
+package servers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// Creates a config with safe mode and a web hook receiving node and safe mode events along with two probed servers on each of two nodes.
+func testHealth(name string) (*config.Config, *config.Panel, []config.Server, *testHook) {
+	hook := newTestHook()
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.SafeMode = true
+	cfg.SafeAPIErrors = 3
+	cfg.SafeNodeFails = 3
+	cfg.SafeWindow = 60
+	cfg.Misc = []config.Misc{{Type: "webhook", Data: map[string]interface{}{"url": hook.Server.URL, "app": "slack", "events": []interface{}{"nodedown", "nodeup", "safemode"}}}}
+
+	panel := &config.Panel{Name: name}
+
+	// Forget the panel's health from previous runs (e.g. with -count).
+	health.Lock()
+
+	for scope := range health.Scopes {
+		if scope == PanelScope(panel) || strings.HasSuffix(scope, " on panel "+name) {
+			delete(health.Scopes, scope)
+		}
+	}
+
+	health.Unlock()
+
+	list := []config.Server{}
+
+	for i, node := range []string{"1", "1", "2", "2"} {
+		srv := testServer(name+string(rune('a'+i)), 27015)
+		srv.Panel = name
+		srv.Node = node
+
+		SetProbed(panel, &srv, true)
+
+		// The panel answered for every server before.
+		ReportResources(cfg, panel, &srv, false)
+
+		list = append(list, srv)
+	}
+
+	return cfg, panel, list, hook
+}
+
+func TestPanelOutageIsntNodeOutage(t *testing.T) {
+	cfg, panel, list, hook := testHealth("health-dead")
+	defer hook.Server.Close()
+
+	// The whole panel stops answering.
+	for scan := 0; scan < 3; scan++ {
+		for i := range list {
+			ReportResources(cfg, panel, &list[i], true)
+		}
+	}
+
+	if !health.IsSafe(PanelScope(panel)) {
+		t.Error("panel didn't enter safe mode")
+	}
+
+	for i := range list {
+		if health.IsSafe(NodeScope(panel, &list[i])) {
+			t.Errorf("node %s entered safe mode due to the panel outage", list[i].Node)
+		}
+	}
+
+	for _, body := range hook.Bodies {
+		if strings.Contains(body, "NODE") {
+			t.Errorf("node event fired due to the panel outage: %s", body)
+		}
+	}
+}
+
+func TestNodeOutage(t *testing.T) {
+	cfg, panel, list, hook := testHealth("health-node")
+	defer hook.Server.Close()
+
+	// Node 1's Wings daemon is unreachable while the panel keeps answering for node 2.
+	for scan := 0; scan < 2; scan++ {
+		for i := range list {
+			ReportResources(cfg, panel, &list[i], list[i].Node == "1")
+		}
+	}
+
+	if !health.IsSafe(NodeScope(panel, &list[0])) {
+		t.Error("node 1 didn't enter safe mode")
+	}
+
+	if health.IsSafe(NodeScope(panel, &list[2])) || health.IsSafe(PanelScope(panel)) {
+		t.Error("node 2 or the panel entered safe mode")
+	}
+
+	if hook.Count() != 1 || !strings.Contains(hook.Bodies[0], "NODE DOWN") {
+		t.Errorf("got %v, want a single node down event", hook.Bodies)
+	}
+}
@@ -1,40 +1,62 @@
 package servers
 
 import (
-	"net"
+	"strconv"
 	"time"
 
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
 )
 
-type Tuple struct {
-	IP   string
-	Port int
-	UID  string
-}
-
+// Stats of a watched server. They're carried over when a server's watcher is rebuilt.
 type Stats struct {
-	Fails    *int
-	Restarts *int
-	NextScan *int64
+	Key        string
+	Fails      int
+	Restarts   int
+	NextScan   int64
+	Paused     string
+	State      string
+	StateSince time.Time
+	DownSince  time.Time
+	GaveUp     time.Time
+	NoStart    bool
+	Stopped    bool
+	NotSent    int
 }
 
-type TickerHolder struct {
-	Info      Tuple
-	Ticker    *time.Ticker
-	Conn      *net.UDPConn
-	ScanTime  int
-	Destroyer *chan bool
-	Idx       *int
-	Stats     Stats
+// Remembers when the server's outage started unless it's already known.
+func (st *Stats) MarkDown() {
+	if st.DownSince.IsZero() {
+		st.DownSince = time.Now()
+	}
 }
 
-func RemoveTicker(t *[]TickerHolder, idx int) {
-	copy((*t)[idx:], (*t)[idx+1:])
-	*t = (*t)[:len(*t)-1]
+// A watched server. Reloaded configs are sent through Updates and manual resets through Resets. The watcher is stopped by closing Quit. It exits once it's idle (e.g. after a running restart), stores its final stats, and closes Done.
+type Watcher struct {
+	Key     string
+	Updates chan *config.Config
+	Resets  chan struct{}
+	Quit    chan struct{}
+	Done    chan struct{}
+	Stats   Stats
 }
 
-func RemoveServer(cfg *config.Config, idx int) {
-	copy(cfg.Servers[idx:], cfg.Servers[idx+1:])
-	cfg.Servers = cfg.Servers[:len(cfg.Servers)-1]
+// Retrieves the keys identifying each server by its panel and UID. Duplicate servers (e.g. the same UID with different ports) are numbered.
+func ServerKeys(list []config.Server) []string {
+	keys := []string{}
+	seen := make(map[string]int)
+
+	for _, srv := range list {
+		key := srv.Panel + "/" + srv.UID
+
+		if n := seen[key]; n > 0 {
+			seen[key]++
+			key += "#" + strconv.Itoa(n)
+		} else {
+			seen[key] = 1
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys
 }
@@ -0,0 +1,152 @@
+package servers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/events"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/pterodactyl"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// State of a resource rule for a single server.
+type RuleState struct {
+	Since     time.Time
+	Triggered bool
+}
+
+// Retrieves the value of a rule's resource. Memory and disk are in MB, CPU is in percent, and network is in KB/s. Returns false if the value isn't available (e.g. a percentage without a limit).
+func ResourceValue(rule config.ResourceRule, cur pterodactyl.Resources, prev *pterodactyl.Resources, elapsed float64, limits pterodactyl.Limits) (float64, bool) {
+	switch rule.Resource {
+	case "cpu":
+		if rule.Percent {
+			if limits.CPU < 1 {
+				return 0, false
+			}
+
+			return cur.CPU / float64(limits.CPU) * 100, true
+		}
+
+		return cur.CPU, true
+
+	case "memory":
+		mb := float64(cur.Memory) / 1024 / 1024
+
+		if rule.Percent {
+			if limits.Memory < 1 {
+				return 0, false
+			}
+
+			return mb / float64(limits.Memory) * 100, true
+		}
+
+		return mb, true
+
+	case "disk":
+		mb := float64(cur.Disk) / 1024 / 1024
+
+		if rule.Percent {
+			if limits.Disk < 1 {
+				return 0, false
+			}
+
+			return mb / float64(limits.Disk) * 100, true
+		}
+
+		return mb, true
+
+	case "netrx", "nettx":
+		// Network counters are totals, so we need a previous sample to calculate a rate.
+		if rule.Percent || prev == nil || elapsed <= 0 {
+			return 0, false
+		}
+
+		delta := cur.NetRX - prev.NetRX
+
+		if rule.Resource == "nettx" {
+			delta = cur.NetTX - prev.NetTX
+		}
+
+		// Counters reset when the container restarts.
+		if delta < 0 {
+			return 0, false
+		}
+
+		return float64(delta) / 1024 / elapsed, true
+	}
+
+	return 0, false
+}
+
+// Compares a value against a rule.
+func CompareRule(rule config.ResourceRule, val float64) bool {
+	switch rule.Op {
+	case ">=":
+		return val >= rule.Value
+	case "<":
+		return val < rule.Value
+	case "<=":
+		return val <= rule.Value
+	case "==":
+		return val == rule.Value
+	}
+
+	return val > rule.Value
+}
+
+// Checks the server's resource rules. Rules with the "warn" action fire a warning event once per breach. Returns true along with the reason if a rule with the "fail" action is exceeded.
+func CheckRules(cfg *config.Config, srv *config.Server, states []RuleState, cur pterodactyl.Resources, prev *pterodactyl.Resources, elapsed float64, limits pterodactyl.Limits) (bool, string) {
+	failed := false
+	reason := ""
+
+	for i, rule := range srv.Rules {
+		val, ok := ResourceValue(rule, cur, prev, elapsed, limits)
+
+		// If the condition isn't met, reset the rule's state.
+		if !ok || !CompareRule(rule, val) {
+			states[i].Since = time.Time{}
+			states[i].Triggered = false
+
+			continue
+		}
+
+		if states[i].Since.IsZero() {
+			states[i].Since = time.Now()
+		}
+
+		// Check if the condition has held long enough.
+		if time.Since(states[i].Since) < time.Duration(rule.Duration)*time.Second {
+			continue
+		}
+
+		unit := ""
+
+		if rule.Percent {
+			unit = "%"
+		}
+
+		why := rule.Resource + " " + rule.Op + " " + strconv.FormatFloat(rule.Value, 'f', -1, 64) + unit + " for " + strconv.Itoa(rule.Duration) + " seconds (current " + strconv.FormatFloat(val, 'f', 2, 64) + unit + ")"
+
+		if cfg.DebugLevel > 1 {
+			fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Resource rule #" + strconv.Itoa(i) + " exceeded with action " + rule.Action + ". " + why + " (" + srv.Name + ").")
+		}
+
+		if rule.Action == "warn" {
+			// Only warn once per breach.
+			if !states[i].Triggered {
+				events.OnResourceWarn(cfg, srv, why)
+			}
+
+			states[i].Triggered = true
+
+			continue
+		}
+
+		states[i].Triggered = true
+		failed = true
+		reason = why
+	}
+
+	return failed, reason
+}
@@ -0,0 +1,173 @@
+package servers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/pterodactyl"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+const mb = 1024 * 1024
+
+func TestResourceValue(t *testing.T) {
+	cur := pterodactyl.Resources{Memory: 512 * mb, CPU: 150, Disk: 2048 * mb, NetRX: 30 * 1024, NetTX: 100 * 1024}
+	prev := &pterodactyl.Resources{NetRX: 10 * 1024, NetTX: 200 * 1024}
+	limits := pterodactyl.Limits{Memory: 1024, Disk: 4096, CPU: 200}
+
+	tests := []struct {
+		Name   string
+		Rule   config.ResourceRule
+		Prev   *pterodactyl.Resources
+		Limits pterodactyl.Limits
+		Value  float64
+		OK     bool
+	}{
+		{"cpu", config.ResourceRule{Resource: "cpu"}, nil, limits, 150, true},
+		{"cpu percent", config.ResourceRule{Resource: "cpu", Percent: true}, nil, limits, 75, true},
+		{"memory", config.ResourceRule{Resource: "memory"}, nil, limits, 512, true},
+		{"memory percent", config.ResourceRule{Resource: "memory", Percent: true}, nil, limits, 50, true},
+		{"disk percent", config.ResourceRule{Resource: "disk", Percent: true}, nil, limits, 50, true},
+		{"memory percent without limit", config.ResourceRule{Resource: "memory", Percent: true}, nil, pterodactyl.Limits{}, 0, false},
+		{"cpu percent without limit", config.ResourceRule{Resource: "cpu", Percent: true}, nil, pterodactyl.Limits{}, 0, false},
+		{"netrx rate", config.ResourceRule{Resource: "netrx"}, prev, limits, 10, true},
+		{"netrx without previous sample", config.ResourceRule{Resource: "netrx"}, nil, limits, 0, false},
+		{"nettx counter reset", config.ResourceRule{Resource: "nettx"}, prev, limits, 0, false},
+		{"network percent", config.ResourceRule{Resource: "netrx", Percent: true}, prev, limits, 0, false},
+		{"unknown resource", config.ResourceRule{Resource: "mem"}, nil, limits, 0, false},
+	}
+
+	for _, test := range tests {
+		val, ok := ResourceValue(test.Rule, cur, test.Prev, 2, test.Limits)
+
+		if ok != test.OK || val != test.Value {
+			t.Errorf("%s: got %v (%v), want %v (%v)", test.Name, val, ok, test.Value, test.OK)
+		}
+	}
+}
+
+func TestCompareRuleDefaultOp(t *testing.T) {
+	rule := config.ResourceRule{Resource: "cpu", Value: 90}
+
+	if CompareRule(rule, 90) || !CompareRule(rule, 91) {
+		t.Error("rules without an operator don't use >")
+	}
+
+	rule.Op = "<="
+
+	if !CompareRule(rule, 90) || CompareRule(rule, 91) {
+		t.Error("operator isn't respected")
+	}
+}
+
+// A web hook counting the events it receives.
+type testHook struct {
+	sync.Mutex
+	Server *httptest.Server
+	Bodies []string
+}
+
+func newTestHook() *testHook {
+	th := &testHook{}
+
+	th.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		th.Lock()
+		th.Bodies = append(th.Bodies, string(body))
+		th.Unlock()
+	}))
+
+	return th
+}
+
+func (th *testHook) Count() int {
+	th.Lock()
+	defer th.Unlock()
+
+	return len(th.Bodies)
+}
+
+func TestCheckRules(t *testing.T) {
+	hook := newTestHook()
+	defer hook.Server.Close()
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.Misc = []config.Misc{{Type: "webhook", Data: map[string]interface{}{"url": hook.Server.URL, "app": "slack"}}}
+
+	srv := testServer("rules", 27015)
+	srv.Rules = []config.ResourceRule{
+		{Resource: "memory", Value: 256, Action: "warn"},
+		{Resource: "cpu", Value: 100},
+	}
+
+	states := make([]RuleState, len(srv.Rules))
+	cur := pterodactyl.Resources{Memory: 512 * mb, CPU: 50}
+
+	// Warn rules fire once per breach and never fail the scan.
+	for i := 0; i < 3; i++ {
+		failed, _ := CheckRules(cfg, &srv, states, cur, nil, 0, pterodactyl.Limits{})
+
+		if failed {
+			t.Fatal("warn rule failed the scan")
+		}
+	}
+
+	if hook.Count() != 1 || !strings.Contains(hook.Bodies[0], "RESOURCE WARNING") {
+		t.Fatalf("got %d warnings, want 1: %v", hook.Count(), hook.Bodies)
+	}
+
+	// A new breach warns again.
+	CheckRules(cfg, &srv, states, pterodactyl.Resources{Memory: 128 * mb}, nil, 0, pterodactyl.Limits{})
+	CheckRules(cfg, &srv, states, cur, nil, 0, pterodactyl.Limits{})
+
+	if hook.Count() != 2 {
+		t.Errorf("got %d warnings after a new breach, want 2", hook.Count())
+	}
+
+	// Rules without an action fail the scan.
+	failed, reason := CheckRules(cfg, &srv, states, pterodactyl.Resources{Memory: 128 * mb, CPU: 150}, nil, 0, pterodactyl.Limits{})
+
+	if !failed || !strings.HasPrefix(reason, "cpu ") {
+		t.Errorf("default action didn't fail the scan (reason %q)", reason)
+	}
+}
+
+func TestCheckRulesDuration(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+
+	srv := testServer("rules", 27015)
+	srv.Rules = []config.ResourceRule{{Resource: "cpu", Op: ">=", Value: 100, Duration: 60, Action: "fail"}}
+
+	states := make([]RuleState, len(srv.Rules))
+	cur := pterodactyl.Resources{CPU: 100}
+
+	if failed, _ := CheckRules(cfg, &srv, states, cur, nil, 0, pterodactyl.Limits{}); failed {
+		t.Fatal("rule failed before its duration held")
+	}
+
+	// Pretend the condition started a minute ago.
+	states[0].Since = time.Now().Add(-61 * time.Second)
+
+	if failed, _ := CheckRules(cfg, &srv, states, cur, nil, 0, pterodactyl.Limits{}); !failed {
+		t.Fatal("rule didn't fail after its duration held")
+	}
+
+	// Dropping below the threshold resets the duration.
+	CheckRules(cfg, &srv, states, pterodactyl.Resources{CPU: 10}, nil, 0, pterodactyl.Limits{})
+
+	if !states[0].Since.IsZero() || states[0].Triggered {
+		t.Errorf("rule state wasn't reset: %+v", states[0])
+	}
+
+	if failed, _ := CheckRules(cfg, &srv, states, cur, nil, 0, pterodactyl.Limits{}); failed {
+		t.Error("rule failed right after it was reset")
+	}
+}
@@ -0,0 +1,444 @@
+package servers
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/events"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/misc"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/pterodactyl"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/query"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// How long to wait for a container to go offline after it was killed (in seconds).
+const KillTimeout = 15
+
+// How many restarts in a row that couldn't be sent aren't counted towards max restarts (e.g. while the panel is briefly unavailable). Afterwards, they're counted so a permanent failure (e.g. a token without the power permission) gives up eventually.
+const MaxNotSent = 3
+
+// Restart results.
+const (
+	// The server is back up and answering queries.
+	RestartSuccess = iota
+
+	// The power actions were sent, but the server didn't come back.
+	RestartFailed
+
+	// The power actions were rejected or couldn't be sent.
+	RestartNotSent
+)
+
+// Restarts the server's container using the server's restart mode and verifies it comes back. Returns the restart result along with the reason on failure.
+func RestartServer(cfg *config.Config, panel *config.Panel, srv *config.Server, conn *net.UDPConn) (int, string) {
+	// Get restart mode.
+	mode := srv.RestartMode
+
+	if mode == "" {
+		mode = "kill"
+	}
+
+	// Get stop timeout.
+	stoptimeout := srv.StopTimeout
+
+	if stoptimeout < 1 {
+		stoptimeout = 30
+	}
+
+	if cfg.DebugLevel > 1 {
+		fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Restarting server with mode " + mode + ". Stop timeout => " + strconv.Itoa(stoptimeout) + ". Start timeout => " + strconv.Itoa(srv.StartTimeout) + ". Verify timeout => " + strconv.Itoa(srv.VerifyTimeout) + " (" + srv.Name + ").")
+	}
+
+	// Send pre-restart commands (e.g. warnings and saves).
+	RunCommands(cfg, panel, srv)
+
+	// Back up the server before restarting it if needed.
+	if srv.Backup {
+		backuptimeout := srv.BackupTimeout
+
+		if backuptimeout < 1 {
+			backuptimeout = 600
+		}
+
+		if cfg.DebugLevel > 1 {
+			fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Creating backup before restart. Backup timeout => " + strconv.Itoa(backuptimeout) + " (" + srv.Name + ").")
+		}
+
+		err := pterodactyl.BackupServer(cfg, panel, srv.UID, backuptimeout)
+
+		// A failed backup shouldn't leave the server down. Therefore, report it and continue with the restart.
+		if err != nil {
+			fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to back up server before restart (" + srv.Name + ").")
+			fmt.Println(err)
+
+			events.OnBackupFail(cfg, srv, err.Error())
+		}
+	}
+
+	switch mode {
+	case "schedule":
+		// Let the panel's schedule restart the server so it shows up in the panel's activity.
+		schedtimeout := srv.SchedTimeout
+
+		if schedtimeout < 1 {
+			schedtimeout = 300
+		}
+
+		sent, err := pterodactyl.RunSchedule(panel, srv.UID, srv.Schedule, schedtimeout)
+
+		if err != nil {
+			// Retrying doesn't help with a missing schedule, so it counts as a failed restart.
+			if err == pterodactyl.ErrScheduleNotFound {
+				return RestartFailed, "schedule '" + srv.Schedule + "' not found"
+			}
+
+			if !sent {
+				return RestartNotSent, "failed to execute schedule: " + err.Error()
+			}
+
+			return RestartFailed, err.Error()
+		}
+
+	case "restart":
+		// Use Pterodactyl's restart signal.
+		if !pterodactyl.RestartServer(panel, srv.UID) {
+			return RestartNotSent, "failed to send restart signal"
+		}
+
+		// Wait for the container to leave the running state. If we miss it, the restart was quick enough.
+		pterodactyl.WaitForState(panel, srv.UID, stoptimeout, "stopping", "offline", "starting")
+
+	case "stop":
+		// Attempt to gracefully stop the container.
+		if !pterodactyl.StopServer(panel, srv.UID) {
+			return RestartNotSent, "failed to send stop signal"
+		}
+
+		// If the container didn't stop in time, escalate to a kill.
+		if !pterodactyl.WaitForState(panel, srv.UID, stoptimeout, "offline") {
+			if cfg.DebugLevel > 0 {
+				fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Server didn't stop within " + strconv.Itoa(stoptimeout) + " seconds. Killing (" + srv.Name + ").")
+			}
+
+			if !pterodactyl.KillServer(panel, srv.UID) {
+				return RestartNotSent, "failed to send kill signal"
+			}
+
+			if !pterodactyl.WaitForState(panel, srv.UID, KillTimeout, "offline") {
+				return RestartFailed, "container did not go offline after kill"
+			}
+		}
+
+		// Now start the container.
+		if !pterodactyl.StartServer(panel, srv.UID) {
+			return RestartNotSent, "failed to send start signal"
+		}
+
+	default:
+		if res, reason := KillAndStart(cfg, panel, srv); res != RestartSuccess {
+			return res, reason
+		}
+	}
+
+	return VerifyStart(cfg, panel, srv, conn)
+}
+
+// Kills the server's container, waits for it to go offline, and sends the start signal. Returns RestartSuccess once the start signal is sent.
+func KillAndStart(cfg *config.Config, panel *config.Panel, srv *config.Server) (int, string) {
+	// Attempt to kill container.
+	if !pterodactyl.KillServer(panel, srv.UID) {
+		return RestartNotSent, "failed to send kill signal"
+	}
+
+	// Wait for the container to actually go offline before starting.
+	if !pterodactyl.WaitForState(panel, srv.UID, KillTimeout, "offline") {
+		return RestartFailed, "container did not go offline after kill"
+	}
+
+	// Now attempt to start it again.
+	if !pterodactyl.StartServer(panel, srv.UID) {
+		return RestartNotSent, "failed to send start signal"
+	}
+
+	return RestartSuccess, ""
+}
+
+// Kills and starts the server's container regardless of its restart mode and verifies it comes back.
+func ForceRestart(cfg *config.Config, panel *config.Panel, srv *config.Server, conn *net.UDPConn) (int, string) {
+	if res, reason := KillAndStart(cfg, panel, srv); res != RestartSuccess {
+		return res, reason
+	}
+
+	return VerifyStart(cfg, panel, srv, conn)
+}
+
+// Starts the server's container after it was found offline and verifies it comes back. Returns the restart result along with the reason on failure.
+func StartOffline(cfg *config.Config, panel *config.Panel, srv *config.Server, conn *net.UDPConn) (int, string) {
+	if !pterodactyl.StartServer(panel, srv.UID) {
+		return RestartNotSent, "failed to send start signal"
+	}
+
+	return VerifyStart(cfg, panel, srv, conn)
+}
+
+// Waits for the server's container to be running and the server to answer queries after a start signal was sent.
+func VerifyStart(cfg *config.Config, panel *config.Panel, srv *config.Server, conn *net.UDPConn) (int, string) {
+	starttimeout := srv.StartTimeout
+
+	if starttimeout < 1 {
+		starttimeout = 120
+	}
+
+	verifytimeout := srv.VerifyTimeout
+
+	if verifytimeout < 1 {
+		verifytimeout = 300
+	}
+
+	// Wait for the container to come back.
+	if !pterodactyl.WaitForState(panel, srv.UID, starttimeout, "running") {
+		return RestartFailed, "container did not come back online within " + strconv.Itoa(starttimeout) + " seconds"
+	}
+
+	// Wait for the game server to answer queries.
+	if !WaitForResponse(cfg, srv, conn, verifytimeout) {
+		return RestartFailed, "server did not answer queries within " + strconv.Itoa(verifytimeout) + " seconds of the container running"
+	}
+
+	return RestartSuccess, ""
+}
+
+// Checks whether the server may be restarted (the restart limit isn't reached and the restart interval passed).
+func CanRestart(srv *config.Server, restarts int, nextscan int64) bool {
+	return restarts < srv.MaxRestarts && nextscan < time.Now().Unix()
+}
+
+// Retrieves the server's restart interval and ensures it's at least 1.
+func RestartInterval(srv *config.Server) int {
+	if srv.RestartInt < 1 {
+		return 120
+	}
+
+	return srv.RestartInt
+}
+
+// Performs a restart action (unless the server is report only), fires the result events, and sets the next scan time. The restart must already be counted.
+func DoRestart(cfg *config.Config, srv *config.Server, stats *Stats, why string, action func() (int, string)) {
+	fails := &stats.Fails
+	restarts := &stats.Restarts
+	nextscan := &stats.NextScan
+
+	result := "report only"
+
+	// Check if we want to restart the container.
+	if !srv.ReportOnly {
+		stats.SetState(cfg, srv, StateRestarting, why)
+
+		start := time.Now()
+
+		res, reason := action()
+
+		// The restart never happened, so don't count it unless it keeps failing.
+		if res == RestartNotSent {
+			stats.NotSent++
+
+			if stats.NotSent <= MaxNotSent {
+				*restarts--
+			}
+		} else {
+			stats.NotSent = 0
+		}
+
+		switch res {
+		case RestartSuccess:
+			if cfg.DebugLevel > 0 {
+				fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Server restarted successfully (" + srv.Name + ").")
+			}
+
+			events.OnRestartSuccess(cfg, srv, *fails, *restarts, time.Since(start))
+
+			result = "restarted"
+
+		default:
+			fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to restart server. Reason => " + reason + " (" + srv.Name + ").")
+
+			events.OnRestartFail(cfg, srv, *fails, *restarts, reason)
+
+			result = "restart failed: " + reason
+		}
+	}
+
+	// Get new scan time.
+	*nextscan = time.Now().Unix() + int64(RestartInterval(srv))
+
+	stats.SetState(cfg, srv, StateCooldown, result)
+}
+
+// Starts a server that's expected to be running, but was found offline. This uses the same restart limits as other restarts.
+func HandleOffline(cfg *config.Config, panel *config.Panel, srv *config.Server, conn *net.UDPConn, stats *Stats) {
+	// Auto-start may be disabled after giving up on the server.
+	if stats.NoStart {
+		return
+	}
+
+	if !CanRestart(srv, stats.Restarts, stats.NextScan) {
+		if stats.Restarts >= srv.MaxRestarts {
+			GiveUp(cfg, panel, srv, stats, "max restarts reached")
+		}
+
+		return
+	}
+
+	fails := &stats.Fails
+	restarts := &stats.Restarts
+
+	// Increment restarts count.
+	*restarts++
+
+	if cfg.DebugLevel > 0 {
+		fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Server found offline unexpectedly. Report Only => " + strconv.FormatBool(srv.ReportOnly) + ". Restart Count => " + strconv.Itoa(*restarts) + " (" + srv.Name + ").")
+	}
+
+	// Report only servers aren't started. Therefore, report them as down instead.
+	if srv.ReportOnly {
+		ReportDown(cfg, panel, srv, stats, "container found offline")
+	} else {
+		events.OnAutoStart(cfg, srv, *fails, *restarts)
+	}
+
+	DoRestart(cfg, srv, stats, "container found offline", func() (int, string) {
+		return StartOffline(cfg, panel, srv, conn)
+	})
+}
+
+// Kills and starts a server whose container is stuck in the starting or stopping state. This uses the same restart limits as other restarts.
+func HandleStuck(cfg *config.Config, panel *config.Panel, srv *config.Server, conn *net.UDPConn, stats *Stats, state string, stuck time.Duration) {
+	if !CanRestart(srv, stats.Restarts, stats.NextScan) {
+		if stats.Restarts >= srv.MaxRestarts {
+			GiveUp(cfg, panel, srv, stats, "max restarts reached")
+		}
+
+		return
+	}
+
+	fails := &stats.Fails
+	restarts := &stats.Restarts
+
+	// Increment restarts count.
+	*restarts++
+
+	reason := "container stuck in " + state + " state for " + strconv.Itoa(int(stuck.Seconds())) + " seconds"
+
+	if cfg.DebugLevel > 0 {
+		fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Server found stuck. Reason => " + reason + ". Report Only => " + strconv.FormatBool(srv.ReportOnly) + ". Restart Count => " + strconv.Itoa(*restarts) + " (" + srv.Name + ").")
+	}
+
+	events.OnServerStuck(cfg, srv, *fails, *restarts, reason)
+
+	DoRestart(cfg, srv, stats, reason, func() (int, string) {
+		return ForceRestart(cfg, panel, srv, conn)
+	})
+}
+
+// Checks whether the server was intentionally stopped (the stop marker file exists or the latest power action in the activity log is a stop or kill).
+func StoppedByAdmin(cfg *config.Config, panel *config.Panel, srv *config.Server) bool {
+	// Check for the stop marker.
+	if len(srv.StopMarker) > 0 {
+		exists, err := pterodactyl.FileExists(panel, srv.UID, srv.StopMarker)
+
+		if err != nil {
+			fmt.Println(err)
+		}
+
+		if exists {
+			if cfg.DebugLevel > 2 {
+				fmt.Println("[D3][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Found stop marker " + srv.StopMarker + " (" + srv.Name + ").")
+			}
+
+			return true
+		}
+	}
+
+	// Check the activity log. If it's unavailable (e.g. older panels), assume the stop was unexpected.
+	action, err := pterodactyl.GetLastPowerAction(panel, srv.UID)
+
+	if err != nil {
+		if cfg.DebugLevel > 2 {
+			fmt.Println("[D3][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to retrieve activity log (" + srv.Name + ").")
+			fmt.Println(err)
+		}
+
+		return false
+	}
+
+	if cfg.DebugLevel > 2 {
+		fmt.Println("[D3][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Last power action => " + action + " (" + srv.Name + ").")
+	}
+
+	return action == "stop" || action == "kill"
+}
+
+// Fires a down event for the server and remembers when the outage started for the recovery (up) event.
+func ReportDown(cfg *config.Config, panel *config.Panel, srv *config.Server, stats *Stats, reason string) {
+	events.OnServerDown(cfg, srv, stats.Fails, stats.Restarts, reason, GetLogTail(cfg, panel, srv))
+
+	stats.MarkDown()
+}
+
+// Retrieves the last lines of the server's log file for notifications. Returns an empty string if no log file is set, no web hook receives down events, or the log can't be read.
+func GetLogTail(cfg *config.Config, panel *config.Panel, srv *config.Server) string {
+	// Logs are only included in down events.
+	if len(srv.LogFile) < 1 || !misc.WantsAny(cfg, "down") {
+		return ""
+	}
+
+	lines := srv.LogLines
+
+	if lines < 1 {
+		lines = 20
+	}
+
+	tail, err := pterodactyl.TailFile(panel, srv.UID, srv.LogFile, lines)
+
+	if err != nil {
+		fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to read log file " + srv.LogFile + " (" + srv.Name + ").")
+		fmt.Println(err)
+
+		return ""
+	}
+
+	return tail
+}
+
+// Sends the server's pre-restart console commands, waiting each command's delay (in seconds) afterwards.
+func RunCommands(cfg *config.Config, panel *config.Panel, srv *config.Server) {
+	for _, cmd := range srv.Commands {
+		if len(cmd.Command) < 1 {
+			continue
+		}
+
+		if cfg.DebugLevel > 2 {
+			fmt.Println("[D3][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Sending command '" + cmd.Command + "' and waiting " + strconv.Itoa(cmd.Delay) + " seconds (" + srv.Name + ").")
+		}
+
+		// A hung server may not accept commands. Therefore, continue with the restart regardless.
+		if !pterodactyl.SendCommand(panel, srv.UID, cmd.Command) {
+			fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to send command '" + cmd.Command + "' (" + srv.Name + ").")
+		}
+
+		if cmd.Delay > 0 {
+			time.Sleep(time.Duration(cmd.Delay) * time.Second)
+		}
+	}
+}
+
+// Sends A2S_INFO requests until the server responds or the timeout (in seconds) is reached. Returns true if the server responded.
+func WaitForResponse(cfg *config.Config, srv *config.Server, conn *net.UDPConn, timeout int) bool {
+	return pterodactyl.Poll(timeout, func() bool {
+		query.SendRequest(conn)
+
+		return query.CheckResponse(conn, *srv)
+	})
+}
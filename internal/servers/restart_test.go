@@ -0,0 +1,89 @@
+package servers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+func TestDoRestartCountsRepeatedNotSent(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+
+	srv := testServer("rejected", 27015)
+	stats := Stats{}
+
+	notsent := func() (int, string) {
+		return RestartNotSent, "failed to send restart signal"
+	}
+
+	// Restarts that couldn't be sent aren't counted at first (the caller counts each restart beforehand).
+	for i := 0; i < MaxNotSent; i++ {
+		stats.Restarts++
+
+		DoRestart(cfg, &srv, &stats, "query timeout", notsent)
+
+		if stats.Restarts != 0 {
+			t.Fatalf("unsent restart #%d was counted (%d restarts)", i+1, stats.Restarts)
+		}
+	}
+
+	// Afterwards, they count towards max restarts so the server gives up eventually.
+	stats.Restarts++
+
+	DoRestart(cfg, &srv, &stats, "query timeout", notsent)
+
+	if stats.Restarts != 1 {
+		t.Fatalf("repeated unsent restart wasn't counted (%d restarts)", stats.Restarts)
+	}
+
+	// A restart that was sent starts counting unsent restarts again.
+	stats.Restarts++
+
+	DoRestart(cfg, &srv, &stats, "query timeout", func() (int, string) {
+		return RestartFailed, "server didn't come back"
+	})
+
+	if stats.NotSent != 0 || stats.Restarts != 2 {
+		t.Errorf("sent restart didn't clear unsent restarts: %+v", stats)
+	}
+
+	stats.Restarts++
+
+	DoRestart(cfg, &srv, &stats, "query timeout", notsent)
+
+	if stats.Restarts != 2 {
+		t.Errorf("first unsent restart after a sent one was counted (%d restarts)", stats.Restarts)
+	}
+}
+
+func TestRestartScheduleNotFound(t *testing.T) {
+	// The server has no schedules at all.
+	panel := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/schedules") {
+			w.Write([]byte(`{"object":"list","data":[]}`))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	defer panel.Close()
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+
+	srv := testServer("nosched", 27015)
+	srv.RestartMode = "schedule"
+	srv.Schedule = "Restart"
+
+	res, reason := RestartServer(cfg, &config.Panel{Name: "test", APIURL: panel.URL + "/", Token: "client"}, &srv, nil)
+
+	if res != RestartFailed || !strings.Contains(reason, "'Restart' not found") {
+		t.Errorf("missing schedule returned %d (%s), want a failed restart", res, reason)
+	}
+}
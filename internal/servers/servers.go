@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/events"
@@ -12,85 +13,380 @@ import (
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
 )
 
-var tickers []TickerHolder
+// How often a server's limits are retrieved again for resource rules.
+var LimitsInterval = time.Minute * 5
+
+// Watched servers by server key. Only the goroutine handling reloads starts and stops watchers, but the lock keeps the registry safe regardless.
+var watchers = make(map[string]*Watcher)
+var watchersLock sync.Mutex
+
+// Watches a server. The goroutine owns the server's config, its stats, and its config snapshot. Other goroutines only communicate with it through the watcher's channels.
+func ServerWatch(w *Watcher, srv *config.Server, cfg *config.Config, stats Stats, timer *time.Ticker, conn *net.UDPConn, extraconns []*net.UDPConn) Stats {
+	fails := &stats.Fails
+	restarts := &stats.Restarts
+	nextscan := &stats.NextScan
+	paused := &stats.Paused
+
+	// Resource rule states and the previous resource sample (used for rates).
+	var rules []RuleState
+	var prev *pterodactyl.Resources
+	var prevtime time.Time
+	var limits *pterodactyl.Limits
+	var limitstime time.Time
+
+	// The container's current state and when it entered that state.
+	state := ""
+	statesince := time.Now()
+
+	// Whether the warmup period of the container's current run ended early and the container's last uptime (used to detect restarts between scans).
+	warmed := false
+	var uptime int64
+
+	// Whether the container was offline on the last scan and whether that was intentional.
+	offline := false
+	adminstop := false
 
-// Timer function.
-func ServerWatch(srv *config.Server, timer *time.Ticker, fails *int, restarts *int, nextscan *int64, conn *net.UDPConn, cfg *config.Config, destroy *chan bool) {
 	for {
 		select {
 		case <-timer.C:
-			// If the UDP connection or server is nil, break the timer.
-			if conn == nil || srv == nil {
-				*destroy <- true
+			// Check if server is enabled.
+			if !srv.Enable {
+				continue
+			}
+
+			// Retrieve the server's panel.
+			panel := cfg.GetPanel(srv.Panel)
 
-				break
+			if panel == nil {
+				fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Panel '" + srv.Panel + "' not found (" + srv.Name + ").")
+
+				continue
 			}
 
-			// Check if server is enabled.
-			if !srv.Enable {
+			// Retry a server we gave up on once its give up reset time passed or its reset marker was created.
+			if !stats.GaveUp.IsZero() || stats.NoStart {
+				reset := ""
+
+				if !stats.GaveUp.IsZero() && srv.GiveUpReset > 0 && time.Since(stats.GaveUp) >= time.Duration(srv.GiveUpReset)*time.Second {
+					reset = "give up reset after " + strconv.Itoa(srv.GiveUpReset) + " seconds"
+				} else if FoundResetMarker(cfg, panel, srv) {
+					reset = "reset marker found"
+				}
+
+				if len(reset) > 0 {
+					stats.ResetGiveUp(cfg, panel, srv, reset)
+
+					// Check whether an offline container was stopped intentionally again (e.g. the give up action stopped it).
+					offline = false
+					adminstop = false
+				}
+			}
+
+			// Retrieve container status and resource utilization.
+			res, err := pterodactyl.GetResources(panel, srv.UID)
+
+			// Track API errors to detect panel and node outages.
+			ReportResources(cfg, panel, srv, err != nil)
+
+			if err != nil {
+				fmt.Println(err)
+
+				stats.SetState(cfg, srv, StateUnknown, err.Error())
+
+				continue
+			}
+
+			// Check if the server can't be watched right now (e.g. suspended, installing or transferring).
+			if res.Paused != *paused {
+				if len(res.Paused) > 0 {
+					if cfg.DebugLevel > 0 {
+						fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Pausing server. Reason => " + res.Paused + " (" + srv.Name + ").")
+					}
+
+					// Notify on install failures if needed.
+					if srv.NotifyInstall && (res.Paused == "install_failed" || res.Paused == "reinstall_failed") {
+						events.OnInstallFail(cfg, srv, res.Paused)
+					}
+				} else if cfg.DebugLevel > 0 {
+					fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Resuming server (" + srv.Name + ").")
+				}
+
+				*paused = res.Paused
+			}
+
+			if len(*paused) > 0 {
+				SetProbed(panel, srv, false)
+
+				if *paused == "node_maintenance" {
+					stats.SetState(cfg, srv, StateMaintenance, *paused)
+				} else {
+					stats.SetState(cfg, srv, StatePaused, *paused)
+				}
+
 				continue
 			}
 
+			// Track how long the container has been in its current state.
+			if res.State != state {
+				state = res.State
+				statesince = time.Now()
+			}
+
 			// Check if container status is 'on'.
-			if !pterodactyl.CheckStatus(cfg, srv.UID) {
+			if res.State != "running" {
+				SetProbed(panel, srv, false)
+
+				rules = nil
+				prev = nil
+				warmed = false
+
+				// Starting containers aren't expected to answer queries yet. Containers that stopped or crashed are failing (paused is only used for states reported by the panel).
+				if res.State == "starting" {
+					stats.SetState(cfg, srv, StateStartingGrace, "container starting")
+				} else if stats.GaveUp.IsZero() {
+					stats.SetState(cfg, srv, StateFailing, "container "+res.State)
+				}
+
+				// Don't act on the container while its panel or node is in safe mode.
+				if InSafeMode(panel, srv) {
+					continue
+				}
+
+				// Check if the container is stuck starting or stopping.
+				if (state == "starting" && srv.MaxStarting > 0 && time.Since(statesince) > time.Duration(srv.MaxStarting)*time.Second) || (state == "stopping" && srv.MaxStopping > 0 && time.Since(statesince) > time.Duration(srv.MaxStopping)*time.Second) {
+					HandleStuck(cfg, panel, srv, conn, &stats, state, time.Since(statesince))
+
+					continue
+				}
+
+				// Check if the server should be kept running.
+				if res.State == "offline" && srv.KeepRunning {
+					// Only act once the container was offline for a full scan (e.g. not in the middle of a restart from the panel).
+					if !offline {
+						offline = true
+						adminstop = StoppedByAdmin(cfg, panel, srv)
+
+						continue
+					}
+
+					if !adminstop {
+						HandleOffline(cfg, panel, srv, conn, &stats)
+					}
+				} else {
+					offline = false
+				}
+
 				continue
 			}
 
+			offline = false
+
+			SetProbed(panel, srv, true)
+
+			// A container that restarted between scans starts a new warmup period.
+			if res.Resources.Uptime < uptime {
+				warmed = false
+			}
+
+			uptime = res.Resources.Uptime
+
+			// Check if the server is still warming up after its container started running (from any cause). Pterodactyl reports the container's uptime in milliseconds.
+			started := statesince
+
+			if uptime > 0 {
+				started = time.Now().Add(-time.Duration(uptime) * time.Millisecond)
+			}
+
+			warmup := srv.WarmUp > 0 && !warmed && time.Since(started) < time.Duration(srv.WarmUp)*time.Second
+
+			failed := false
+			reason := ""
+
+			// Check resource rules.
+			if len(srv.Rules) > 0 {
+				// Retrieve the server's limits and refresh them periodically (they may be changed on the panel).
+				if limits == nil || time.Since(limitstime) > LimitsInterval {
+					details, err := pterodactyl.GetDetails(panel, srv.UID)
+
+					if err != nil {
+						fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Failed to retrieve server limits (" + srv.Name + ").")
+						fmt.Println(err)
+					} else {
+						limits = &details.Attributes.Limits
+						limitstime = time.Now()
+					}
+				}
+
+				if limits != nil {
+					if len(rules) != len(srv.Rules) {
+						rules = make([]RuleState, len(srv.Rules))
+					}
+
+					failed, reason = CheckRules(cfg, srv, rules, res.Resources, prev, time.Since(prevtime).Seconds(), *limits)
+				}
+
+				prev = &res.Resources
+				prevtime = time.Now()
+			}
+
 			// Send A2S_INFO request.
+			sent := time.Now()
+
 			query.SendRequest(conn)
 
 			if cfg.DebugLevel > 2 {
 				fmt.Println("[D3][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] A2S_INFO sent (" + srv.Name + ").")
 			}
 
-			// Check for response. If no response, increase fail count. Otherwise, reset fail count to 0.
+			// Check for response.
+			unreachable := false
+
 			if !query.CheckResponse(conn, *srv) {
+				unreachable = true
+				reason = "no A2S_INFO response"
+			}
+
+			latency := time.Since(sent)
+
+			// Check extra allocations.
+			for _, econn := range extraconns {
+				query.SendRequest(econn)
+
+				if !query.CheckResponse(econn, *srv) {
+					unreachable = true
+					reason = "no A2S_INFO response from " + econn.RemoteAddr().String()
+				}
+			}
+
+			// Check extra TCP allocations (e.g. RCON ports).
+			for _, addr := range srv.Extra {
+				if addr.Proto != "tcp" {
+					continue
+				}
+
+				if !query.CheckTCP(addr.IP, addr.Port, srv.A2STimeout) {
+					unreachable = true
+					reason = "no TCP connection to " + addr.IP + ":" + strconv.Itoa(addr.Port)
+				}
+			}
+
+			// Track query failures to detect node outages. Servers that are warming up aren't expected to answer.
+			ReportQuery(cfg, panel, srv, unreachable && !warmup)
+
+			if unreachable {
+				failed = true
+			}
+
+			// Failed checks don't count while the server is warming up.
+			if failed && warmup {
+				if cfg.DebugLevel > 1 {
+					fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Ignoring failed check while warming up. Reason => " + reason + ". Running for => " + time.Since(started).Round(time.Second).String() + " (" + srv.Name + ").")
+				}
+
+				stats.SetState(cfg, srv, StateStartingGrace, "warming up")
+
+				continue
+			}
+
+			// End the warmup period early once the server answers if needed.
+			if !failed && warmup && srv.WarmUpEarly {
+				warmed = true
+			}
+
+			// If the server failed a check, increase fail count. Otherwise, reset fail count to 0.
+			if failed {
 				// Increase fail count.
 				*fails++
 
 				if cfg.DebugLevel > 1 {
-					fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Fails => " + strconv.Itoa(*fails))
+					fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Fails => " + strconv.Itoa(*fails) + ". Reason => " + reason)
 				}
 
-				// Check to see if we want to restart the server.
-				if *fails >= srv.MaxFails && *restarts < srv.MaxRestarts && *nextscan < time.Now().Unix() {
-					// Check if we want to restart the container.
-					if !srv.ReportOnly {
-						// Attempt to kill container.
-						pterodactyl.KillServer(cfg, srv.UID)
-
-						// Now attempt to start it again.
-						pterodactyl.StartServer(cfg, srv.UID)
+				// Update the server's state if it won't be restarted.
+				if *fails < srv.MaxFails {
+					stats.SetState(cfg, srv, StateDegraded, reason)
+				} else if *restarts >= srv.MaxRestarts {
+					GiveUp(cfg, panel, srv, &stats, "max restarts reached")
+
+					// Keep reporting the outage each restart interval if the server switched to report only.
+					if srv.GiveUpAction == "reportonly" && *nextscan < time.Now().Unix() {
+						ReportDown(cfg, panel, srv, &stats, reason)
+
+						*nextscan = time.Now().Unix() + int64(RestartInterval(srv))
 					}
+				} else if *nextscan >= time.Now().Unix() {
+					stats.SetState(cfg, srv, StateCooldown, reason)
+				}
 
-					// Increment restarts count.
-					*restarts++
+				// Check to see if we want to restart the server.
+				if *fails >= srv.MaxFails && CanRestart(srv, *restarts, *nextscan) {
+					// Don't restart while the server's panel or node is in safe mode.
+					if InSafeMode(panel, srv) {
+						if cfg.DebugLevel > 1 {
+							fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Skipping restart due to safe mode (" + srv.Name + ").")
+						}
 
-					// Set next scan time and ensure the restart interval is at least 1.
-					restartint := srv.RestartInt
+						stats.SetState(cfg, srv, StateFailing, "safe mode")
 
-					if restartint < 1 {
-						restartint = 120
+						continue
 					}
 
-					// Get new scan time.
-					*nextscan = time.Now().Unix() + int64(restartint)
+					// Increment restarts count.
+					*restarts++
 
 					// Debug.
 					if cfg.DebugLevel > 0 {
 						fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Server found down. Report Only => " + strconv.FormatBool(srv.ReportOnly) + ". Fail Count => " + strconv.Itoa(*fails) + ". Restart Count => " + strconv.Itoa(*restarts) + " (" + srv.Name + ").")
 					}
 
-					events.OnServerDown(cfg, srv, *fails, *restarts)
+					ReportDown(cfg, panel, srv, &stats, reason)
+
+					DoRestart(cfg, srv, &stats, reason, func() (int, string) {
+						return RestartServer(cfg, panel, srv, conn)
+					})
 				}
 			} else {
+				// Notify that a server we reported as down answers again.
+				if !stats.DownSince.IsZero() {
+					if cfg.DebugLevel > 0 {
+						fmt.Println("[D1][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Server back up. Downtime => " + time.Since(stats.DownSince).Round(time.Second).String() + ". Restart Count => " + strconv.Itoa(*restarts) + ". Latency => " + latency.Round(time.Millisecond).String() + " (" + srv.Name + ").")
+					}
+
+					events.OnServerUp(cfg, srv, *restarts, time.Since(stats.DownSince), latency)
+
+					stats.DownSince = time.Time{}
+				}
+
 				// Reset everything.
 				*fails = 0
 				*restarts = 0
 				*nextscan = 0
+
+				stats.GaveUp = time.Time{}
+				stats.NoStart = false
+				stats.Stopped = false
+				stats.NotSent = 0
+
+				stats.SetState(cfg, srv, StateHealthy, "server answered queries")
 			}
 
-		case <-*destroy:
+		case newcfg := <-w.Updates:
+			// Apply reloaded global settings (e.g. debug level, panels, and misc options).
+			cfg = newcfg
+
+			// Retrieve the server's limits again on the next scan.
+			limits = nil
+
+		case <-w.Resets:
+			// Reset a server we gave up on manually.
+			if !stats.GaveUp.IsZero() || stats.NoStart {
+				stats.ResetGiveUp(cfg, cfg.GetPanel(srv.Panel), srv, "manual reset")
+
+				offline = false
+				adminstop = false
+			}
+
+		case <-w.Quit:
 			// Close UDP connection and check.
 			err := conn.Close()
 
@@ -99,131 +395,237 @@ func ServerWatch(srv *config.Server, timer *time.Ticker, fails *int, restarts *i
 				fmt.Println(err)
 			}
 
+			for _, econn := range extraconns {
+				econn.Close()
+			}
+
 			// Stop timer/ticker.
 			timer.Stop()
 
-			// Stop function.
-			return
+			// The server no longer counts towards its node.
+			if panel := cfg.GetPanel(srv.Panel); panel != nil {
+				SetProbed(panel, srv, false)
+			}
+
+			// Hand the stats over to whoever waits on us (e.g. a new watcher after a reload).
+			return stats
 		}
 	}
 }
 
-func HandleServers(cfg *config.Config, update bool) {
-	stats := make(map[Tuple]Stats)
+// Starts watching a server. The watcher works on its own copy of the server's config so reloads don't modify it while it's running. If a previous watcher is specified, the new watcher waits for it to exit in the background and carries its stats over. A watcher still registered under the key is stopped and replaced the same way. Therefore, this never blocks on a running restart.
+func StartWatcher(cfg *config.Config, key string, srv config.Server, prev *Watcher, update bool) {
+	// If we're not enabled, ignore.
+	if !srv.Enable {
+		return
+	}
 
-	// Retrieve current server stats before removing tickers
-	for _, srvticker := range tickers {
-		for _, srv := range cfg.Servers {
-			// Create tuple.
-			var srvt Tuple
-			srvt.IP = srv.IP
-			srvt.Port = srv.Port
-			srvt.UID = srv.UID
+	if cfg.DebugLevel > 0 && !update {
+		fmt.Println("[D1] Adding server " + srv.IP + ":" + strconv.Itoa(srv.Port) + " with UID " + srv.UID + ". Auto Add => " + strconv.FormatBool(srv.ViaAPI) + ". Scan time => " + strconv.Itoa(srv.ScanTime) + ". Max Fails => " + strconv.Itoa(srv.MaxFails) + ". Max Restarts => " + strconv.Itoa(srv.MaxRestarts) + ". Restart Interval => " + strconv.Itoa(srv.RestartInt) + ". Report Only => " + strconv.FormatBool(srv.ReportOnly) + ". Enabled => " + strconv.FormatBool(srv.Enable) + ". Name => " + srv.Name + ". A2S Timeout => " + strconv.Itoa(srv.A2STimeout) + ". Mentions => " + srv.Mentions + ". Restart Mode => " + srv.RestartMode + ". Stop Timeout => " + strconv.Itoa(srv.StopTimeout) + ". Start Timeout => " + strconv.Itoa(srv.StartTimeout) + ". Verify Timeout => " + strconv.Itoa(srv.VerifyTimeout) + ". Commands => " + strconv.Itoa(len(srv.Commands)) + ". Backup => " + strconv.FormatBool(srv.Backup) + ". Backup Timeout => " + strconv.Itoa(srv.BackupTimeout) + ". Schedule => " + srv.Schedule + ". Schedule Timeout => " + strconv.Itoa(srv.SchedTimeout) + ". Log File => " + srv.LogFile + ". Log Lines => " + strconv.Itoa(srv.LogLines) + ". Rules => " + strconv.Itoa(len(srv.Rules)) + ". Keep Running => " + strconv.FormatBool(srv.KeepRunning) + ". Stop Marker => " + srv.StopMarker + ". Max Starting => " + strconv.Itoa(srv.MaxStarting) + ". Max Stopping => " + strconv.Itoa(srv.MaxStopping) + ". Warmup => " + strconv.Itoa(srv.WarmUp) + ". Warmup Early => " + strconv.FormatBool(srv.WarmUpEarly) + ". Give Up Action => " + srv.GiveUpAction + ". Give Up Reset => " + strconv.Itoa(srv.GiveUpReset) + ". Reset Marker => " + srv.ResetMarker + ". Extra Allocations => " + strconv.Itoa(len(srv.Extra)) + ". Status => " + srv.Status + ". Panel => " + srv.Panel + ". Node => " + srv.Node + ".")
+	}
 
-			if cfg.DebugLevel > 4 {
-				fmt.Println("[D5] HandleServers :: Comparing " + srvt.IP + ":" + strconv.Itoa(srvt.Port) + ":" + srvt.UID + " == " + srv.IP + ":" + strconv.Itoa(srv.Port) + ":" + srv.UID + ".")
-			}
+	// Get scan time.
+	stime := srv.ScanTime
 
-			if srvt == srvticker.Info {
-				if cfg.DebugLevel > 3 {
-					fmt.Println("[D4] HandleServers :: Found match on " + srvt.IP + ":" + strconv.Itoa(srvt.Port) + ":" + srvt.UID + ".")
-				}
+	if stime < 1 {
+		stime = 5
+	}
 
-				// Fill in stats.
-				stats[srvt] = Stats{
-					Fails:    srvticker.Stats.Fails,
-					Restarts: srvticker.Stats.Restarts,
-					NextScan: srvticker.Stats.NextScan,
-				}
+	// Let's create the connection now.
+	conn, err := query.CreateConnection(srv.IP, srv.Port)
 
-			}
-		}
+	if err != nil {
+		fmt.Println("Error creating UDP connection for " + srv.IP + ":" + strconv.Itoa(srv.Port) + " ( " + srv.Name + ").")
+		fmt.Println(err)
 
-		// Destroy ticker.
-		*srvticker.Destroyer <- true
+		return
 	}
 
-	// Remove servers that should be deleted.
-	for i, srv := range cfg.Servers {
-		if srv.Delete {
-			if cfg.DebugLevel > 1 {
-				fmt.Println("[D2] Found server that should be deleted UID => " + srv.UID + ". Name => " + srv.Name + ". IP => " + srv.IP + ". Port => " + strconv.Itoa(srv.Port) + ".")
-			}
+	// Create connections for extra allocations. TCP allocations are connected to on each scan instead.
+	extraconns := []*net.UDPConn{}
 
-			RemoveServer(cfg, i)
+	for _, addr := range srv.Extra {
+		if addr.Proto == "tcp" {
+			continue
 		}
-	}
 
-	tickers = []TickerHolder{}
+		econn, err := query.CreateConnection(addr.IP, addr.Port)
+
+		if err != nil {
+			fmt.Println("Error creating UDP connection for " + addr.IP + ":" + strconv.Itoa(addr.Port) + " ( " + srv.Name + ").")
+			fmt.Println(err)
 
-	// Loop through each container from the config.
-	for i, srv := range cfg.Servers {
-		// If we're not enabled, ignore.
-		if !srv.Enable {
 			continue
 		}
 
-		// Create tuple.
-		var srvt Tuple
-		srvt.IP = srv.IP
-		srvt.Port = srv.Port
-		srvt.UID = srv.UID
-
-		// Specify server-specific variables
-		var fails int = 0
-		var restarts int = 0
-		var nextscan int64 = 0
-
-		// Replace stats with old ticker's stats.
-		if stat, ok := stats[srvt]; ok {
-			fails = *stat.Fails
-			restarts = *stat.Restarts
-			nextscan = *stat.NextScan
-		}
+		extraconns = append(extraconns, econn)
+	}
 
-		if cfg.DebugLevel > 0 && !update {
-			fmt.Println("[D1] Adding server " + srv.IP + ":" + strconv.Itoa(srv.Port) + " with UID " + srv.UID + ". Auto Add => " + strconv.FormatBool(srv.ViaAPI) + ". Scan time => " + strconv.Itoa(srv.ScanTime) + ". Max Fails => " + strconv.Itoa(srv.MaxFails) + ". Max Restarts => " + strconv.Itoa(srv.MaxRestarts) + ". Restart Interval => " + strconv.Itoa(srv.RestartInt) + ". Report Only => " + strconv.FormatBool(srv.ReportOnly) + ". Enabled => " + strconv.FormatBool(srv.Enable) + ". Name => " + srv.Name + ". A2S Timeout => " + strconv.Itoa(srv.A2STimeout) + ". Mentions => " + srv.Mentions + ".")
+	if cfg.DebugLevel > 3 {
+		fmt.Println("[D4] Creating timer for " + srv.IP + ":" + strconv.Itoa(srv.Port) + ":" + srv.UID + " (" + srv.Name + ").")
+	}
+
+	// Create the watcher's channels.
+	w := &Watcher{
+		Key:     key,
+		Updates: make(chan *config.Config, 1),
+		Resets:  make(chan struct{}, 1),
+		Quit:    make(chan struct{}),
+		Done:    make(chan struct{}),
+	}
+
+	// Add watcher to registry. A watcher already registered under the key is stopped and hands its stats over unless a previous watcher was passed.
+	watchersLock.Lock()
+
+	if old, ok := watchers[key]; ok {
+		if cfg.DebugLevel > 3 {
+			fmt.Println("[D4] Replacing timer for " + key + ".")
 		}
 
-		// Get scan time.
-		stime := srv.ScanTime
+		close(old.Quit)
 
-		if stime < 1 {
-			stime = 5
+		if prev == nil {
+			prev = old
 		}
+	}
 
-		// Let's create the connection now.
-		conn, err := query.CreateConnection(srv.IP, srv.Port)
+	watchers[key] = w
+	watchersLock.Unlock()
 
-		if err != nil {
-			fmt.Println("Error creating UDP connection for " + srv.IP + ":" + strconv.Itoa(srv.Port) + " ( " + srv.Name + ").")
-			fmt.Println(err)
+	go func() {
+		// Servers start unpaused so the first scan notifies about a status found during discovery (e.g. a failed install).
+		stats := Stats{}
 
-			continue
+		// Replace stats with the previous watcher's stats once it exited.
+		if prev != nil {
+			stats = prev.Wait()
 		}
 
-		if cfg.DebugLevel > 3 {
-			fmt.Println("[D4] Creating timer for " + srv.IP + ":" + strconv.Itoa(srv.Port) + ":" + srv.UID + " (" + srv.Name + ").")
+		// New servers start in the unknown state until they're checked.
+		stats.Key = key
+
+		if len(stats.State) < 1 {
+			stats.State = StateUnknown
+			stats.StateSince = time.Now()
 		}
 
-		// Create destroyer channel.
-		destroyer := make(chan bool)
+		PublishState(key, StateInfo{Name: srv.Name, State: stats.State, Since: stats.StateSince})
 
 		// Create repeating timer.
 		ticker := time.NewTicker(time.Duration(stime) * time.Second)
-		go ServerWatch(&cfg.Servers[i], ticker, &fails, &restarts, &nextscan, conn, cfg, &destroyer)
-
-		// Add ticker to global list.
-		var newticker TickerHolder
-		newticker.Info = srvt
-		newticker.Ticker = ticker
-		newticker.Conn = conn
-		newticker.ScanTime = stime
-		newticker.Destroyer = &destroyer
-		newticker.Stats.Fails = &fails
-		newticker.Stats.Restarts = &restarts
-		newticker.Stats.NextScan = &nextscan
-
-		tickers = append(tickers, newticker)
+
+		w.Stats = ServerWatch(w, &srv, cfg, stats, ticker, conn, extraconns)
+
+		// Remove the published state unless a new watcher took over the server.
+		watchersLock.Lock()
+
+		if _, ok := watchers[key]; !ok {
+			ClearState(key)
+		}
+
+		watchersLock.Unlock()
+
+		close(w.Done)
+	}()
+}
+
+// Stops watching a server without waiting for the watcher to exit (it may be restarting its server). Returns the stopped watcher or nil if the server wasn't watched.
+func StopWatcher(cfg *config.Config, key string) *Watcher {
+	watchersLock.Lock()
+	w, ok := watchers[key]
+	delete(watchers, key)
+	watchersLock.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	if cfg.DebugLevel > 3 {
+		fmt.Println("[D4] Destroying timer for " + key + ".")
+	}
+
+	close(w.Quit)
+
+	return w
+}
+
+// Waits for a stopped watcher to exit and retrieves its final stats.
+func (w *Watcher) Wait() Stats {
+	<-w.Done
+
+	return w.Stats
+}
+
+// Sends a reloaded config to a watcher. Only the latest config is kept if the watcher is busy (e.g. restarting its server).
+func (w *Watcher) Update(cfg *config.Config) {
+	select {
+	case <-w.Updates:
+	default:
+	}
+
+	w.Updates <- cfg
+}
+
+// Sends a manual reset to a watcher. Resets are dropped if one is already pending.
+func (w *Watcher) Reset() {
+	select {
+	case w.Resets <- struct{}{}:
+	default:
+	}
+}
+
+// Resets all servers we gave up on (e.g. on SIGUSR1).
+func ResetServers() {
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+
+	for _, w := range watchers {
+		w.Reset()
+	}
+}
+
+// Starts watching all servers from the config.
+func HandleServers(cfg *config.Config, update bool) {
+	keys := ServerKeys(cfg.Servers)
+
+	// Loop through each container from the config.
+	for i, srv := range cfg.Servers {
+		StartWatcher(cfg, keys[i], srv, nil, update)
+	}
+}
+
+// Rebuilds watchers for servers that were added, removed, or changed by a reload. Watchers of unchanged servers keep running and receive the reloaded config. Changed servers keep their stats. This doesn't wait for stopped watchers (e.g. while they restart their server).
+func SyncServers(cfg *config.Config, added map[string]config.Server, removed map[string]config.Server, changed map[string]config.Server) {
+	watchersLock.Lock()
+
+	for key, w := range watchers {
+		if _, ok := changed[key]; !ok {
+			w.Update(cfg)
+		}
+	}
+
+	watchersLock.Unlock()
+
+	for key := range removed {
+		StopWatcher(cfg, key)
+	}
+
+	for key, srv := range changed {
+		StartWatcher(cfg, key, srv, StopWatcher(cfg, key), true)
+	}
+
+	for key, srv := range added {
+		StartWatcher(cfg, key, srv, nil, true)
+	}
+
+	// Retry servers whose watchers couldn't be started before (e.g. their host couldn't be resolved).
+	for i, key := range ServerKeys(cfg.Servers) {
+		watchersLock.Lock()
+		_, ok := watchers[key]
+		watchersLock.Unlock()
+
+		if !ok {
+			StartWatcher(cfg, key, cfg.Servers[i], nil, true)
+		}
 	}
 }
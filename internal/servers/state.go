@@ -0,0 +1,146 @@
+package servers
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/events"
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// Server health states.
+const (
+	// The server's condition can't be determined (e.g. the panel API failed or the watcher just started).
+	StateUnknown = "unknown"
+
+	// The container is starting and the server isn't expected to answer queries yet.
+	StateStartingGrace = "starting-grace"
+
+	// The server answers queries.
+	StateHealthy = "healthy"
+
+	// The server failed checks, but not enough to be restarted.
+	StateDegraded = "degraded"
+
+	// The server reached its max fails, but can't be restarted right now (e.g. safe mode).
+	StateFailing = "failing"
+
+	// The server is being restarted or started.
+	StateRestarting = "restarting"
+
+	// The server was restarted and won't be restarted again until the restart interval passed.
+	StateCooldown = "cooldown"
+
+	// The server reached its max restarts and is only watched until it recovers.
+	StateGaveUp = "gave-up"
+
+	// The server can't be watched (e.g. suspended, installing, or its container isn't running).
+	StatePaused = "paused"
+
+	// The server's node is under maintenance.
+	StateMaintenance = "maintenance"
+)
+
+// States that may be entered from every state.
+var AnyStates = []string{StateUnknown, StatePaused, StateMaintenance}
+
+// Allowed transitions between states (in addition to AnyStates).
+var Transitions = map[string][]string{
+	StateUnknown:       {StateStartingGrace, StateHealthy, StateDegraded, StateFailing, StateRestarting, StateCooldown, StateGaveUp},
+	StateStartingGrace: {StateHealthy, StateDegraded, StateFailing, StateRestarting, StateCooldown, StateGaveUp},
+	StateHealthy:       {StateStartingGrace, StateDegraded, StateFailing, StateRestarting, StateCooldown, StateGaveUp},
+	StateDegraded:      {StateStartingGrace, StateHealthy, StateFailing, StateRestarting, StateCooldown, StateGaveUp},
+	StateFailing:       {StateStartingGrace, StateHealthy, StateDegraded, StateRestarting, StateCooldown, StateGaveUp},
+	StateRestarting:    {StateCooldown},
+	StateCooldown:      {StateStartingGrace, StateHealthy, StateDegraded, StateFailing, StateRestarting, StateGaveUp},
+	StateGaveUp:        {StateStartingGrace, StateHealthy, StateRestarting},
+	StatePaused:        {StateStartingGrace, StateHealthy, StateDegraded, StateFailing, StateRestarting, StateCooldown, StateGaveUp},
+	StateMaintenance:   {StateStartingGrace, StateHealthy, StateDegraded, StateFailing, StateRestarting, StateCooldown, StateGaveUp},
+}
+
+// A server's published state.
+type StateInfo struct {
+	Name   string
+	State  string
+	Since  time.Time
+	Reason string
+}
+
+// Published states by server key. Watchers own their state and publish a copy on each transition.
+var states = make(map[string]StateInfo)
+var statesLock sync.Mutex
+
+// Checks whether a transition between two states is defined.
+func CanTransition(from string, to string) bool {
+	for _, s := range AnyStates {
+		if s == to {
+			return true
+		}
+	}
+
+	for _, s := range Transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Moves the server to a new state, publishes it, and fires a state change event. Undefined transitions are reported, but still applied since the state must reflect the server's condition.
+func (st *Stats) SetState(cfg *config.Config, srv *config.Server, to string, reason string) {
+	from := st.State
+
+	if from == to {
+		return
+	}
+
+	if !CanTransition(from, to) {
+		fmt.Println("[ERR][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] Undefined state transition " + from + " => " + to + " (" + srv.Name + ").")
+	}
+
+	took := time.Since(st.StateSince)
+
+	st.State = to
+	st.StateSince = time.Now()
+
+	if cfg.DebugLevel > 1 {
+		fmt.Println("[D2][" + srv.IP + ":" + strconv.Itoa(srv.Port) + "] State " + from + " => " + to + ". Reason => " + reason + " (" + srv.Name + ").")
+	}
+
+	PublishState(st.Key, StateInfo{Name: srv.Name, State: to, Since: st.StateSince, Reason: reason})
+
+	events.OnStateChange(cfg, srv, st.Fails, st.Restarts, from, to, reason, took)
+}
+
+// Publishes a server's state.
+func PublishState(key string, info StateInfo) {
+	statesLock.Lock()
+	defer statesLock.Unlock()
+
+	states[key] = info
+}
+
+// Removes a server's published state.
+func ClearState(key string) {
+	statesLock.Lock()
+	defer statesLock.Unlock()
+
+	delete(states, key)
+}
+
+// Retrieves a copy of all servers' states by server key.
+func States() map[string]StateInfo {
+	statesLock.Lock()
+	defer statesLock.Unlock()
+
+	list := make(map[string]StateInfo)
+
+	for k, v := range states {
+		list[k] = v
+	}
+
+	return list
+}
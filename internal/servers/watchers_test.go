@@ -0,0 +1,388 @@
+package servers
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+// A fake panel reporting every container as running. Power signals are rejected. Power signals for the blocked UID hang until released (like a long restart).
+type testPanel struct {
+	Server  *httptest.Server
+	Blocked string
+	Hit     chan struct{}
+	Release chan struct{}
+}
+
+func newTestPanel(blocked string) *testPanel {
+	tp := &testPanel{Blocked: blocked, Hit: make(chan struct{}, 1), Release: make(chan struct{})}
+
+	tp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/resources"):
+			w.Write([]byte(`{"object":"stats","attributes":{"current_state":"running","is_suspended":false,"resources":{"memory_bytes":1,"cpu_absolute":1,"disk_bytes":1,"network_rx_bytes":1,"network_tx_bytes":1,"uptime":3600000}}}`))
+
+		case strings.HasSuffix(r.URL.Path, "/power"):
+			if strings.Contains(r.URL.Path, "/"+tp.Blocked+"/") {
+				select {
+				case tp.Hit <- struct{}{}:
+				default:
+				}
+
+				select {
+				case <-tp.Release:
+				case <-time.After(4 * time.Second):
+				}
+			}
+
+			w.WriteHeader(http.StatusInternalServerError)
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return tp
+}
+
+// Answers every A2S_INFO request.
+func newTestGameServer(t *testing.T) (*net.UDPConn, int) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		buf := make([]byte, 1024)
+
+		for {
+			_, addr, err := conn.ReadFromUDP(buf)
+
+			if err != nil {
+				return
+			}
+
+			conn.WriteToUDP([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x49}, addr)
+		}
+	}()
+
+	return conn, conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// Retrieves a port nothing listens on (queries fail right away).
+func closedPort(t *testing.T) int {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	return port
+}
+
+func testServer(uid string, port int) config.Server {
+	return config.Server{
+		Name:        uid,
+		Enable:      true,
+		IP:          "127.0.0.1",
+		Port:        port,
+		UID:         uid,
+		ScanTime:    1,
+		MaxFails:    1,
+		MaxRestarts: 1000,
+		RestartInt:  1,
+		A2STimeout:  1,
+		Panel:       "test",
+	}
+}
+
+func testWatcherConfig(tp *testPanel, list []config.Server) *config.Config {
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.SafeMode = false
+	cfg.Panels = []config.Panel{{Name: "test", APIURL: tp.Server.URL + "/", Token: "client"}}
+	cfg.Servers = list
+
+	return cfg
+}
+
+// Retrieves the registered watcher of a server.
+func registered(key string) *Watcher {
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+
+	return watchers[key]
+}
+
+// Stops all watchers and waits for them to exit.
+func stopAll(cfg *config.Config) {
+	watchersLock.Lock()
+	keys := []string{}
+
+	for key := range watchers {
+		keys = append(keys, key)
+	}
+
+	watchersLock.Unlock()
+
+	for _, key := range keys {
+		if w := StopWatcher(cfg, key); w != nil {
+			w.Wait()
+		}
+	}
+}
+
+func TestSyncServersDoesntBlockOnRestart(t *testing.T) {
+	tp := newTestPanel("slow")
+	defer tp.Server.Close()
+
+	slow := testServer("slow", closedPort(t))
+	cfg := testWatcherConfig(tp, []config.Server{slow})
+
+	defer stopAll(cfg)
+
+	HandleServers(cfg, true)
+
+	key := ServerKeys(cfg.Servers)[0]
+	old := registered(key)
+
+	// Wait for the watcher to be inside of its restart.
+	select {
+	case <-tp.Hit:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server wasn't restarted")
+	}
+
+	// A change must be applied right away even though the old watcher is still restarting.
+	changed := slow
+	changed.Name = "slow (renamed)"
+
+	start := time.Now()
+
+	SyncServers(cfg, nil, nil, map[string]config.Server{key: changed})
+
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("SyncServers blocked for %s", took)
+	}
+
+	if w := registered(key); w == nil || w == old {
+		t.Fatal("changed server wasn't rebuilt")
+	}
+
+	// Removing another server while the restart is running mustn't block either.
+	start = time.Now()
+
+	SyncServers(cfg, nil, map[string]config.Server{"test/missing": slow}, nil)
+
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("SyncServers blocked for %s", took)
+	}
+
+	close(tp.Release)
+
+	// The old watcher finishes its restart and hands its stats to the new watcher.
+	stats := old.Wait()
+
+	if stats.NextScan < 1 || stats.Fails < 1 {
+		t.Fatalf("old watcher didn't finish its restart: %+v", stats)
+	}
+
+	carried := StopWatcher(cfg, key).Wait()
+
+	if carried.Fails < stats.Fails || carried.NextScan < stats.NextScan {
+		t.Errorf("stats weren't carried over: old %+v, new %+v", stats, carried)
+	}
+}
+
+func TestWatchersStress(t *testing.T) {
+	// Everything started by the test must exit once it's done.
+	goroutines := runtime.NumGoroutine()
+
+	tp := newTestPanel("blocked")
+	game, port := newTestGameServer(t)
+
+	down := closedPort(t)
+
+	// Half of the servers answer and the other half is restarted (and blocks its watcher) repeatedly.
+	pool := []config.Server{}
+
+	for i := 0; i < 16; i++ {
+		uid := "srv" + strconv.Itoa(i)
+
+		if i%2 == 0 {
+			pool = append(pool, testServer(uid, port))
+		} else {
+			pool = append(pool, testServer(uid, down))
+		}
+	}
+
+	pool = append(pool, testServer("blocked", down))
+
+	cfg := testWatcherConfig(tp, pool)
+
+	HandleServers(cfg, true)
+
+	end := time.Now().Add(5 * time.Second)
+
+	var wg sync.WaitGroup
+
+	// Reloads adding, removing, and changing random servers.
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		rnd := rand.New(rand.NewSource(1))
+
+		for time.Now().Before(end) {
+			added := make(map[string]config.Server)
+			removed := make(map[string]config.Server)
+			changed := make(map[string]config.Server)
+
+			for i, key := range ServerKeys(pool) {
+				srv := pool[i]
+
+				switch rnd.Intn(6) {
+				case 0:
+					removed[key] = srv
+
+				case 1:
+					srv.Name = srv.UID + " " + strconv.Itoa(rnd.Int())
+					changed[key] = srv
+
+				case 2:
+					added[key] = srv
+				}
+			}
+
+			newcfg := testWatcherConfig(tp, pool)
+			newcfg.DebugLevel = rnd.Intn(2)
+
+			start := time.Now()
+
+			SyncServers(newcfg, added, removed, changed)
+
+			if took := time.Since(start); took > 2*time.Second {
+				t.Errorf("SyncServers blocked for %s", took)
+			}
+
+			time.Sleep(time.Duration(rnd.Intn(50)) * time.Millisecond)
+		}
+	}()
+
+	// Starts and stops servers outside of the pool directly.
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; time.Now().Before(end); i++ {
+			key := "test/extra" + strconv.Itoa(i%4)
+
+			if w := StopWatcher(cfg, key); w != nil && i%3 == 0 {
+				w.Wait()
+			}
+
+			StartWatcher(cfg, key, testServer("extra"+strconv.Itoa(i%4), port), nil, true)
+
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	// Resets servers and reads their states.
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for time.Now().Before(end) {
+			ResetServers()
+			States()
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	wg.Wait()
+
+	close(tp.Release)
+
+	// Every enabled server must be watched once the churn settled.
+	SyncServers(cfg, nil, nil, nil)
+
+	for _, key := range ServerKeys(pool) {
+		if registered(key) == nil {
+			t.Errorf("server %s isn't watched", key)
+		}
+	}
+
+	// Re-adding watched servers replaces their watchers instead of leaking them.
+	stopAll(cfg)
+
+	tp.Server.Close()
+	game.Close()
+
+	for start := time.Now(); runtime.NumGoroutine() > goroutines && time.Since(start) < 10*time.Second; {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if n := runtime.NumGoroutine(); n > goroutines {
+		buf := make([]byte, 1<<20)
+
+		t.Errorf("%d goroutines leaked:\n%s", n-goroutines, buf[:runtime.Stack(buf, true)])
+	}
+}
+
+func TestInstallFailOnStart(t *testing.T) {
+	hook := newTestHook()
+	defer hook.Server.Close()
+
+	// The server already failed to install when it's discovered.
+	panel := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/resources") {
+			w.WriteHeader(http.StatusConflict)
+
+			return
+		}
+
+		w.Write([]byte(`{"object":"server","attributes":{"status":"install_failed"}}`))
+	}))
+
+	defer panel.Close()
+
+	srv := testServer("broken", 27015)
+	srv.Status = "install_failed"
+	srv.NotifyInstall = true
+
+	cfg := &config.Config{}
+	cfg.SetDefaults()
+	cfg.SafeMode = false
+	cfg.Panels = []config.Panel{{Name: "test", APIURL: panel.URL + "/", Token: "client"}}
+	cfg.Servers = []config.Server{srv}
+	cfg.Misc = []config.Misc{{Type: "webhook", Data: map[string]interface{}{"url": hook.Server.URL, "app": "slack"}}}
+
+	HandleServers(cfg, true)
+
+	for start := time.Now(); hook.Count() < 1 && time.Since(start) < 5*time.Second; {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	StopWatcher(cfg, ServerKeys(cfg.Servers)[0]).Wait()
+
+	if hook.Count() != 1 || !strings.Contains(hook.Bodies[0], "INSTALL FAILED") {
+		t.Errorf("got %d install failure notifications, want 1: %v", hook.Count(), hook.Bodies)
+	}
+}
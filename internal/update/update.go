@@ -2,201 +2,167 @@ package update
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/events"
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/pterodactyl"
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/internal/servers"
 	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
 )
 
-type Tuple struct {
-	IP   string
-	Port int
-	UID  string
-}
-
 var updateticker *time.Ticker
 
-func AddNewServers(newcfg *config.Config, cfg *config.Config) {
-	// Loop through all new servers.
-	for i, newsrv := range newcfg.Servers {
-		if cfg.DebugLevel > 3 {
-			fmt.Println("[D4] Looking for " + newsrv.IP + ":" + strconv.Itoa(newsrv.Port) + ":" + newsrv.UID + " (" + strconv.Itoa(i) + ") inside of old configuration.")
-		}
-
-		toadd := true
+// Describes what changed between the old and new configuration of a server. Returns an empty string if nothing changed.
+func DescribeChange(oldsrv config.Server, newsrv config.Server) string {
+	changes := []string{}
 
-		// Now loop through old servers.
-		for j, oldsrv := range cfg.Servers {
-			// Create new server tuple.
-			var nt Tuple
-			nt.IP = newsrv.IP
-			nt.Port = newsrv.Port
-			nt.UID = newsrv.UID
+	if oldsrv.IP != newsrv.IP || oldsrv.Port != newsrv.Port {
+		changes = append(changes, "address "+oldsrv.IP+":"+strconv.Itoa(oldsrv.Port)+" => "+newsrv.IP+":"+strconv.Itoa(newsrv.Port))
+	}
 
-			// Create old server tuple.
-			var ot Tuple
-			ot.IP = oldsrv.IP
-			ot.Port = oldsrv.Port
-			ot.UID = oldsrv.UID
+	if !reflect.DeepEqual(oldsrv.Extra, newsrv.Extra) {
+		changes = append(changes, "extra allocations")
+	}
 
-			if cfg.DebugLevel > 4 {
-				fmt.Println("[D5] Comparing " + nt.IP + ":" + strconv.Itoa(nt.Port) + ":" + nt.UID + " == " + ot.IP + ":" + strconv.Itoa(ot.Port) + ":" + ot.UID + " (" + strconv.Itoa(j) + ").")
-			}
+	if oldsrv.Enable != newsrv.Enable {
+		changes = append(changes, "enabled "+strconv.FormatBool(oldsrv.Enable)+" => "+strconv.FormatBool(newsrv.Enable))
+	}
 
-			// Now compare.
-			if nt == ot {
-				// We don't have to insert this server into the slice.
-				toadd = false
-
-				if cfg.DebugLevel > 2 {
-					fmt.Println("[D3] Found matching server (" + newsrv.IP + ":" + strconv.Itoa(newsrv.Port) + ":" + newsrv.UID + ") on Add Server check. Applying new configuration. Name => " + newsrv.Name + ". Enabled: " + strconv.FormatBool(oldsrv.Enable) + " => " + strconv.FormatBool(newsrv.Enable) + ". Max fails: " + strconv.Itoa(oldsrv.MaxFails) + " => " + strconv.Itoa(newsrv.MaxFails) + ". Max Restarts: " + strconv.Itoa(oldsrv.MaxRestarts) + " => " + strconv.Itoa(newsrv.MaxRestarts) + ". Restart Int: " + strconv.Itoa(oldsrv.RestartInt) + " => " + strconv.Itoa(newsrv.RestartInt) + ". Scan Time: " + strconv.Itoa(oldsrv.ScanTime) + " => " + strconv.Itoa(newsrv.ScanTime) + ". Report Only: " + strconv.FormatBool(oldsrv.ReportOnly) + " => " + strconv.FormatBool(newsrv.ReportOnly) + ". A2S Timeout: " + strconv.Itoa(oldsrv.A2STimeout) + " => " + strconv.Itoa(newsrv.A2STimeout) + ". Mentions: " + oldsrv.Mentions + " => " + newsrv.Mentions + ".")
-				}
-
-				// Update specific configuration.
-				cfg.Servers[j].Enable = newsrv.Enable
-				cfg.Servers[j].MaxFails = newsrv.MaxFails
-				cfg.Servers[j].MaxRestarts = newsrv.MaxRestarts
-				cfg.Servers[j].RestartInt = newsrv.RestartInt
-				cfg.Servers[j].ScanTime = newsrv.ScanTime
-				cfg.Servers[j].ReportOnly = newsrv.ReportOnly
-				cfg.Servers[j].A2STimeout = newsrv.A2STimeout
-				cfg.Servers[j].Mentions = newsrv.Mentions
-			}
-		}
+	// Compare everything else. The status is ignored since watchers detect paused servers themselves.
+	oldsrv.IP, oldsrv.Port, oldsrv.Extra, oldsrv.Enable, oldsrv.Status = newsrv.IP, newsrv.Port, newsrv.Extra, newsrv.Enable, newsrv.Status
 
-		// If we're not inside of the current configuration, add the server.
-		if toadd {
-			if cfg.DebugLevel > 1 {
-				fmt.Println("[D2] Adding server from update " + newsrv.IP + ":" + strconv.Itoa(newsrv.Port) + " with UID " + newsrv.UID + ". Name => " + newsrv.Name + ". Auto Add => " + strconv.FormatBool(newsrv.ViaAPI) + ". Scan time => " + strconv.Itoa(newsrv.ScanTime) + ". Max Fails => " + strconv.Itoa(newsrv.MaxFails) + ". Max Restarts => " + strconv.Itoa(newsrv.MaxRestarts) + ". Restart Interval => " + strconv.Itoa(newsrv.RestartInt) + ". Enabled => " + strconv.FormatBool(newsrv.Enable) + ". A2S Timeout => " + strconv.Itoa(newsrv.A2STimeout) + ". Mentions => " + newsrv.Mentions + ".")
-			}
-
-			cfg.Servers = append(cfg.Servers, newsrv)
-		}
+	if !reflect.DeepEqual(oldsrv, newsrv) {
+		changes = append(changes, "settings")
 	}
+
+	return strings.Join(changes, ", ")
 }
 
-func DelOldServers(newcfg *config.Config, cfg *config.Config) {
-	// Loop through all old servers.
-	for i, oldsrv := range cfg.Servers {
-		if cfg.DebugLevel > 3 {
-			fmt.Println("[D4] Looking for " + oldsrv.IP + ":" + strconv.Itoa(oldsrv.Port) + ":" + oldsrv.UID + " (" + strconv.Itoa(i) + ") inside of new configuration.")
-		}
+// Compares the old and new server lists by panel and UID. Returns the servers that were added, removed, and changed (keyed by server key) along with what changed.
+func DiffServers(newcfg *config.Config, cfg *config.Config) (map[string]config.Server, map[string]config.Server, map[string]config.Server, map[string]string) {
+	added := make(map[string]config.Server)
+	removed := make(map[string]config.Server)
+	changed := make(map[string]config.Server)
+	reasons := make(map[string]string)
+
+	oldservers := make(map[string]config.Server)
 
-		todel := true
+	for i, key := range servers.ServerKeys(cfg.Servers) {
+		oldservers[key] = cfg.Servers[i]
+	}
 
-		// Now loop through new servers.
-		for j, newsrv := range newcfg.Servers {
-			// Create old server tuple.
-			var ot Tuple
-			ot.IP = oldsrv.IP
-			ot.Port = oldsrv.Port
-			ot.UID = oldsrv.UID
+	newkeys := servers.ServerKeys(newcfg.Servers)
 
-			// Create new server tuple.
-			var nt Tuple
-			nt.IP = newsrv.IP
-			nt.Port = newsrv.Port
-			nt.UID = newsrv.UID
+	for i, key := range newkeys {
+		newsrv := newcfg.Servers[i]
 
-			if cfg.DebugLevel > 4 {
-				fmt.Println("[D5] Comparing " + ot.IP + ":" + strconv.Itoa(ot.Port) + ":" + ot.UID + " == " + nt.IP + ":" + strconv.Itoa(nt.Port) + ":" + nt.UID + " (" + strconv.Itoa(j) + ").")
-			}
+		oldsrv, ok := oldservers[key]
 
-			// Now compare.
-			if nt == ot {
-				todel = false
-			}
+		if !ok {
+			added[key] = newsrv
+
+			continue
 		}
 
-		// If we're not inside of the new configuration, delete the server.
-		if todel {
-			if cfg.DebugLevel > 1 {
-				fmt.Println("[D2] Deleting server from update " + oldsrv.IP + ":" + strconv.Itoa(oldsrv.Port) + " with UID " + oldsrv.UID + ". Name => " + oldsrv.Name + ".")
-			}
+		delete(oldservers, key)
 
-			// Set Delete to true so we'll delete the server, close the connection, etc. on the next scan.
-			cfg.Servers[i].Delete = true
+		if change := DescribeChange(oldsrv, newsrv); len(change) > 0 {
+			changed[key] = newsrv
+			reasons[key] = change
 		}
 	}
+
+	// Whatever is left isn't a part of the new configuration.
+	for key, oldsrv := range oldservers {
+		removed[key] = oldsrv
+	}
+
+	return added, removed, changed, reasons
 }
 
+// Reloads the config and servers every tick. Configs are never modified once they're in use. Instead, each reload creates a new config which is handed to the watchers through messages.
 func ReloadServers(timer *time.Ticker, cfg *config.Config) {
-	destroy := make(chan struct{})
-
 	for {
-		select {
-		case <-timer.C:
-			// First, we'll want to read the new config.
-			newcfg := config.Config{}
+		<-timer.C
+
+		// First, we'll want to read the new config.
+		newcfg := &config.Config{}
+
+		// Set default values.
+		newcfg.SetDefaults()
+
+		err := newcfg.ReadConfig(cfg.ConfLoc)
+
+		if err != nil {
+			fmt.Println(err)
+
+			continue
+		}
 
-			// Set default values.
-			newcfg.SetDefaults()
+		newcfg.ConfLoc = cfg.ConfLoc
 
-			err := newcfg.ReadConfig(cfg.ConfLoc)
+		cont := pterodactyl.AddServers(newcfg)
 
-			if err != nil {
-				fmt.Println(err)
+		if !cont {
+			fmt.Println("[ERR] Not updating server list due to error.")
 
-				continue
+			continue
+		}
+
+		// If reload time is different, recreate reload timer.
+		if cfg.ReloadTime != newcfg.ReloadTime && newcfg.ReloadTime > 0 {
+			if newcfg.DebugLevel > 2 {
+				fmt.Println("[D3] Recreating update timer due to updated reload time (" + strconv.Itoa(cfg.ReloadTime) + " => " + strconv.Itoa(newcfg.ReloadTime) + ").")
 			}
 
-			if newcfg.AddServers {
-				cont := pterodactyl.AddServers(&newcfg)
+			// Create repeating timer.
+			timer.Stop()
+			timer = time.NewTicker(time.Duration(newcfg.ReloadTime) * time.Second)
+			updateticker = timer
+		}
+
+		// Level 2 debug message.
+		if newcfg.DebugLevel > 1 {
+			fmt.Println("[D2] Updating server list.")
+		}
 
-				if !cont {
-					fmt.Println("[ERR] Not updating server list due to error.")
+		// Compare the new server list against the current one.
+		added, removed, changed, reasons := DiffServers(newcfg, cfg)
 
-					continue
-				}
+		for _, srv := range added {
+			if newcfg.DebugLevel > 1 {
+				fmt.Println("[D2] Adding server from update " + srv.IP + ":" + strconv.Itoa(srv.Port) + " with UID " + srv.UID + ". Name => " + srv.Name + ". Auto Add => " + strconv.FormatBool(srv.ViaAPI) + ".")
 			}
 
-			// Assign new values.
-			cfg.APIURL = newcfg.APIURL
-			cfg.Token = newcfg.Token
-			cfg.DebugLevel = newcfg.DebugLevel
-			cfg.AddServers = newcfg.AddServers
-
-			cfg.DefEnable = newcfg.DefEnable
-			cfg.DefScanTime = newcfg.DefScanTime
-			cfg.DefMaxFails = newcfg.DefMaxFails
-			cfg.DefMaxRestarts = newcfg.DefMaxRestarts
-			cfg.DefRestartInt = newcfg.DefRestartInt
-			cfg.DefReportOnly = newcfg.DefReportOnly
-
-			// If reload time is different, recreate reload timer.
-			if cfg.ReloadTime != newcfg.ReloadTime {
-				if updateticker != nil {
-					updateticker.Stop()
-				}
-
-				if cfg.DebugLevel > 2 {
-					fmt.Println("[D3] Recreating update timer due to updated reload time (" + strconv.Itoa(cfg.ReloadTime) + " => " + strconv.Itoa(newcfg.ReloadTime) + ").")
-				}
-
-				// Create repeating timer.
-				updateticker = time.NewTicker(time.Duration(newcfg.ReloadTime) * time.Second)
-				go ReloadServers(updateticker, cfg)
+			events.OnServerAdded(newcfg, &srv)
+		}
+
+		for _, srv := range removed {
+			if newcfg.DebugLevel > 1 {
+				fmt.Println("[D2] Deleting server from update " + srv.IP + ":" + strconv.Itoa(srv.Port) + " with UID " + srv.UID + ". Name => " + srv.Name + ".")
 			}
 
-			cfg.ReloadTime = newcfg.ReloadTime
+			events.OnServerRemoved(newcfg, &srv)
+		}
 
-			// Level 2 debug message.
-			if cfg.DebugLevel > 1 {
-				fmt.Println("[D2] Updating server list.")
+		for key, srv := range changed {
+			if newcfg.DebugLevel > 1 {
+				fmt.Println("[D2] Updating server from update " + srv.IP + ":" + strconv.Itoa(srv.Port) + " with UID " + srv.UID + ". Name => " + srv.Name + ". Changes => " + reasons[key] + ".")
 			}
 
-			// Add new servers.
-			AddNewServers(&newcfg, cfg)
+			events.OnServerChanged(newcfg, &srv, reasons[key])
+		}
 
-			// Remove servers that are not a part of new configuration.
-			DelOldServers(&newcfg, cfg)
+		// Only rebuild watchers for servers that were added, removed, or changed. The other watchers receive the new config.
+		servers.SyncServers(newcfg, added, removed, changed)
 
-			// Now rehandle servers.
-			servers.HandleServers(cfg, true)
+		cfg = newcfg
 
-		case <-destroy:
+		// Stop reloading if the reload time was disabled.
+		if cfg.ReloadTime < 1 {
 			timer.Stop()
 
 			return
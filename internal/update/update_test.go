@@ -0,0 +1,141 @@
+package update
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gamemann/Pterodactyl-Game-Server-Watch/pkg/config"
+)
+
+func testServer(panel string, uid string, port int) config.Server {
+	return config.Server{Name: uid, Enable: true, IP: "192.0.2.10", Port: port, UID: uid, Panel: panel, ScanTime: 5, MaxFails: 10}
+}
+
+// Retrieves the sorted keys of a server map.
+func keys(m map[string]config.Server) []string {
+	list := []string{}
+
+	for key := range m {
+		list = append(list, key)
+	}
+
+	sort.Strings(list)
+
+	return list
+}
+
+func TestDescribeChange(t *testing.T) {
+	base := testServer("main", "1a7ce997", 28015)
+
+	tests := []struct {
+		Desc   string
+		Change func(srv *config.Server)
+		Want   string
+	}{
+		{"nothing", func(srv *config.Server) {}, ""},
+		{"address", func(srv *config.Server) { srv.Port = 28016 }, "address 192.0.2.10:28015 => 192.0.2.10:28016"},
+		{"extra allocation", func(srv *config.Server) { srv.Extra = []config.Address{{IP: "192.0.2.10", Port: 28016, Proto: "tcp"}} }, "extra allocations"},
+		{"enable", func(srv *config.Server) { srv.Enable = false }, "enabled true => false"},
+		{"settings", func(srv *config.Server) { srv.MaxFails = 3 }, "settings"},
+		{"status only", func(srv *config.Server) { srv.Status = "suspended" }, ""},
+		{"address and settings", func(srv *config.Server) { srv.IP = "192.0.2.11"; srv.ScanTime = 10 }, "address 192.0.2.10:28015 => 192.0.2.11:28015, settings"},
+	}
+
+	for _, test := range tests {
+		newsrv := base
+		test.Change(&newsrv)
+
+		if got := DescribeChange(base, newsrv); got != test.Want {
+			t.Errorf("%s: got %q, want %q", test.Desc, got, test.Want)
+		}
+	}
+}
+
+func TestDescribeChangeExtraAllocation(t *testing.T) {
+	// A changed extra allocation is a change even though the list's length stays the same.
+	oldsrv := testServer("main", "1a7ce997", 28015)
+	oldsrv.Extra = []config.Address{{IP: "192.0.2.10", Port: 28016}}
+
+	newsrv := oldsrv
+	newsrv.Extra = []config.Address{{IP: "192.0.2.10", Port: 28016, Proto: "tcp"}}
+
+	if got := DescribeChange(oldsrv, newsrv); got != "extra allocations" {
+		t.Errorf("got %q, want extra allocations", got)
+	}
+}
+
+func TestDiffServers(t *testing.T) {
+	cfg := &config.Config{Servers: []config.Server{
+		testServer("main", "kept", 28015),
+		testServer("main", "moved", 28015),
+		testServer("main", "readdressed", 28015),
+		testServer("main", "paused", 28015),
+		testServer("main", "gone", 28015),
+	}}
+
+	paused := testServer("main", "paused", 28015)
+	paused.Status = "suspended"
+
+	newcfg := &config.Config{Servers: []config.Server{
+		testServer("main", "kept", 28015),
+		testServer("second", "moved", 28015),
+		testServer("main", "readdressed", 28016),
+		paused,
+		testServer("main", "new", 28015),
+	}}
+
+	added, removed, changed, reasons := DiffServers(newcfg, cfg)
+
+	// A server moving to another panel is removed from the old panel and added to the new one.
+	if want := []string{"main/new", "second/moved"}; !reflect.DeepEqual(keys(added), want) {
+		t.Errorf("added %v, want %v", keys(added), want)
+	}
+
+	if want := []string{"main/gone", "main/moved"}; !reflect.DeepEqual(keys(removed), want) {
+		t.Errorf("removed %v, want %v", keys(removed), want)
+	}
+
+	// Status changes are detected by the watchers themselves.
+	if want := []string{"main/readdressed"}; !reflect.DeepEqual(keys(changed), want) {
+		t.Errorf("changed %v, want %v", keys(changed), want)
+	}
+
+	if !strings.HasPrefix(reasons["main/readdressed"], "address ") {
+		t.Errorf("unexpected reason %q", reasons["main/readdressed"])
+	}
+}
+
+func TestDiffServersDuplicates(t *testing.T) {
+	// The same UID with different ports is numbered by its position.
+	cfg := &config.Config{Servers: []config.Server{
+		testServer("main", "dup", 28015),
+		testServer("main", "dup", 28016),
+	}}
+
+	newcfg := &config.Config{Servers: []config.Server{
+		testServer("main", "dup", 28015),
+		testServer("main", "dup", 28016),
+		testServer("main", "dup", 28017),
+	}}
+
+	added, removed, changed, _ := DiffServers(newcfg, cfg)
+
+	if len(added) != 1 || added["main/dup#2"].Port != 28017 || len(removed) > 0 || len(changed) > 0 {
+		t.Errorf("added %v, removed %v, changed %v; want main/dup#2 added", keys(added), keys(removed), keys(changed))
+	}
+
+	// Removing the first duplicate shifts the others.
+	newcfg.Servers = newcfg.Servers[1:]
+
+	added, removed, changed, reasons := DiffServers(newcfg, cfg)
+
+	if len(added) > 0 || !reflect.DeepEqual(keys(removed), []string{}) || !reflect.DeepEqual(keys(changed), []string{"main/dup", "main/dup#1"}) {
+		t.Errorf("added %v, removed %v, changed %v", keys(added), keys(removed), keys(changed))
+	}
+
+	if reasons["main/dup"] != "address 192.0.2.10:28015 => 192.0.2.10:28016" {
+		t.Errorf("unexpected reason %q", reasons["main/dup"])
+	}
+}
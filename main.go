@@ -41,35 +41,51 @@ func main() {
 
 				os.Exit(1)
 			}
+		} else {
+			// The config exists, but is invalid (e.g. an unknown panel type).
+			fmt.Println("Failed to read config file.")
+			fmt.Println(err)
+
+			os.Exit(1)
 		}
 
 		fmt.Println("WARNING - No config file found. Created config file at " + *configFile + " with defaults.")
 	} else {
-		// Check if we want to automatically add servers.
-		if cfg.AddServers {
-			pterodactyl.AddServers(&cfg)
-		}
+		// Automatically add servers from panels that want it.
+		pterodactyl.AddServers(&cfg)
 	}
 
 	// Level 1 debug.
 	if cfg.DebugLevel > 0 {
-		fmt.Println("[D1] Found config with API URL => " + cfg.APIURL + ". Token => " + cfg.Token + ". App Token => " + cfg.AppToken + ". Auto Add Servers => " + strconv.FormatBool(cfg.AddServers) + ". Debug level => " + strconv.Itoa(cfg.DebugLevel) + ". Reload time => " + strconv.Itoa(cfg.ReloadTime))
+		fmt.Println("[D1] Found config with API URL => " + cfg.APIURL + ". Token => " + cfg.Token + ". App Token => " + cfg.AppToken + ". Auto Add Servers => " + strconv.FormatBool(cfg.AddServers) + ". Discovery => " + cfg.Discovery + ". Debug level => " + strconv.Itoa(cfg.DebugLevel) + ". Reload time => " + strconv.Itoa(cfg.ReloadTime) + ". Panels => " + strconv.Itoa(len(cfg.GetPanels())) + ". Safe Mode => " + strconv.FormatBool(cfg.SafeMode))
 	}
 
 	// Level 2 debug.
 	if cfg.DebugLevel > 1 {
-		fmt.Println("[D2] Config default server values. Enable => " + strconv.FormatBool(cfg.DefEnable) + ". Scan time => " + strconv.Itoa(cfg.DefScanTime) + ". Max Fails => " + strconv.Itoa(cfg.DefMaxFails) + ". Max Restarts => " + strconv.Itoa(cfg.DefMaxRestarts) + ". Restart Interval => " + strconv.Itoa(cfg.DefRestartInt) + ". Report Only => " + strconv.FormatBool(cfg.DefReportOnly) + ". A2S Timeout => " + strconv.Itoa(cfg.DefA2STimeout) + ". Mentions => " + cfg.DefMentions + ".")
+		fmt.Println("[D2] Config default server values. Enable => " + strconv.FormatBool(cfg.DefEnable) + ". Scan time => " + strconv.Itoa(cfg.DefScanTime) + ". Max Fails => " + strconv.Itoa(cfg.DefMaxFails) + ". Max Restarts => " + strconv.Itoa(cfg.DefMaxRestarts) + ". Restart Interval => " + strconv.Itoa(cfg.DefRestartInt) + ". Report Only => " + strconv.FormatBool(cfg.DefReportOnly) + ". A2S Timeout => " + strconv.Itoa(cfg.DefA2STimeout) + ". Mentions => " + cfg.DefMentions + ". Restart Mode => " + cfg.DefRestartMode + ". Stop Timeout => " + strconv.Itoa(cfg.DefStopTimeout) + ". Start Timeout => " + strconv.Itoa(cfg.DefStartTimeout) + ". Verify Timeout => " + strconv.Itoa(cfg.DefVerifyTimeout) + ".")
 	}
 
-	// Handle all servers (create timers, etc.).
-	servers.HandleServers(&cfg, false)
-
 	// Set config file for use later (e.g. updating/reloading).
 	cfg.ConfLoc = *configFile
 
+	// Handle all servers (create timers, etc.). The config isn't modified afterwards since watchers read it.
+	servers.HandleServers(&cfg, false)
+
 	// Initialize updater/reloader.
 	update.Init(&cfg)
 
+	// Reset servers we gave up on when receiving SIGUSR1.
+	resetc := make(chan os.Signal, 1)
+	signal.Notify(resetc, syscall.SIGUSR1)
+
+	go func() {
+		for range resetc {
+			fmt.Println("Resetting servers that were given up on.")
+
+			servers.ResetServers()
+		}
+	}()
+
 	// Signal.
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
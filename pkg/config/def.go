@@ -1,21 +1,99 @@
 package config
 
+// Console command sent to a server before it is restarted.
+type Command struct {
+	Command string `json:"command"`
+	Delay   int    `json:"delay"`
+}
+
+// Additional address to scan for a server. The protocol is either "a2s" (A2S_INFO requests over UDP, default) or "tcp" (a TCP connection, e.g. for RCON ports).
+type Address struct {
+	IP    string `json:"ip"`
+	Port  int    `json:"port"`
+	Proto string `json:"proto"`
+}
+
+// Resource rule checked against a server's resource utilization.
+type ResourceRule struct {
+	Resource string  `json:"resource"`
+	Op       string  `json:"op"`
+	Value    float64 `json:"value"`
+	Percent  bool    `json:"percent"`
+	Duration int     `json:"duration"`
+	Action   string  `json:"action"`
+}
+
 // Server struct used for each server config.
 type Server struct {
-	Name        string `json:"name"`
-	Enable      bool   `json:"enable"`
-	IP          string `json:"ip"`
-	Port        int    `json:"port"`
-	UID         string `json:"uid"`
-	ScanTime    int    `json:"scantime"`
-	MaxFails    int    `json:"maxfails"`
-	MaxRestarts int    `json:"maxrestarts"`
-	RestartInt  int    `json:"restartint"`
-	ReportOnly  bool   `json:"reportonly"`
-	A2STimeout  int    `json:"a2stimeout"`
-	Mentions    string `json:"mentions"`
-	ViaAPI      bool
-	Delete      bool
+	Name          string         `json:"name"`
+	Enable        bool           `json:"enable"`
+	IP            string         `json:"ip"`
+	Port          int            `json:"port"`
+	UID           string         `json:"uid"`
+	ScanTime      int            `json:"scantime"`
+	MaxFails      int            `json:"maxfails"`
+	MaxRestarts   int            `json:"maxrestarts"`
+	RestartInt    int            `json:"restartint"`
+	ReportOnly    bool           `json:"reportonly"`
+	A2STimeout    int            `json:"a2stimeout"`
+	Mentions      string         `json:"mentions"`
+	RestartMode   string         `json:"restartmode"`
+	StopTimeout   int            `json:"stoptimeout"`
+	StartTimeout  int            `json:"starttimeout"`
+	VerifyTimeout int            `json:"verifytimeout"`
+	Commands      []Command      `json:"commands"`
+	Backup        bool           `json:"backup"`
+	BackupTimeout int            `json:"backuptimeout"`
+	Schedule      string         `json:"schedule"`
+	SchedTimeout  int            `json:"scheduletimeout"`
+	LogFile       string         `json:"logfile"`
+	LogLines      int            `json:"loglines"`
+	Rules         []ResourceRule `json:"rules"`
+	KeepRunning   bool           `json:"keeprunning"`
+	StopMarker    string         `json:"stopmarker"`
+	MaxStarting   int            `json:"maxstarting"`
+	MaxStopping   int            `json:"maxstopping"`
+	WarmUp        int            `json:"warmup"`
+	WarmUpEarly   bool           `json:"warmupearly"`
+	GiveUpAction  string         `json:"giveupaction"`
+	GiveUpReset   int            `json:"giveupreset"`
+	ResetMarker   string         `json:"resetmarker"`
+	Extra         []Address      `json:"extra"`
+	NotifyInstall bool           `json:"notifyinstall"`
+	Panel         string         `json:"panel"`
+	Node          string         `json:"node"`
+	ViaAPI        bool
+	Status        string
+}
+
+// Discovery filter criteria. Empty criteria are ignored.
+type FilterSet struct {
+	Nodes       []int    `json:"nodes"`
+	Locations   []int    `json:"locations"`
+	Eggs        []int    `json:"eggs"`
+	Nests       []int    `json:"nests"`
+	Owners      []int    `json:"owners"`
+	ExternalIDs []string `json:"externalids"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+}
+
+// Discovery filters applied to servers retrieved from the Pterodactyl API.
+type Filters struct {
+	Include FilterSet `json:"include"`
+	Exclude FilterSet `json:"exclude"`
+}
+
+// Pterodactyl panel definition.
+type Panel struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"paneltype"`
+	APIURL     string  `json:"apiurl"`
+	Token      string  `json:"token"`
+	AppToken   string  `json:"apptoken"`
+	AddServers bool    `json:"addservers"`
+	Discovery  string  `json:"discovery"`
+	Filters    Filters `json:"filters"`
 }
 
 // Misc options.
@@ -26,21 +104,54 @@ type Misc struct {
 
 // Config struct used for the general config.
 type Config struct {
-	APIURL         string   `json:"apiurl"`
-	Token          string   `json:"token"`
-	AppToken       string   `json:"apptoken"`
-	AddServers     bool     `json:"addservers"`
-	DebugLevel     int      `json:"debug"`
-	ReloadTime     int      `json:"reloadtime"`
-	DefEnable      bool     `json:"defenable"`
-	DefScanTime    int      `json:"defscantime"`
-	DefMaxFails    int      `json:"defmaxfails"`
-	DefMaxRestarts int      `json:"defmaxrestarts"`
-	DefRestartInt  int      `json:"defrestartint"`
-	DefReportOnly  bool     `json:"defreportonly"`
-	DefA2STimeout  int      `json:"defa2stimeout"`
-	DefMentions    string   `json:"defmentions"`
-	Servers        []Server `json:"servers"`
-	Misc           []Misc   `json:"misc"`
-	ConfLoc        string
+	APIURL           string         `json:"apiurl"`
+	PanelType        string         `json:"paneltype"`
+	Token            string         `json:"token"`
+	AppToken         string         `json:"apptoken"`
+	AddServers       bool           `json:"addservers"`
+	Discovery        string         `json:"discovery"`
+	Filters          Filters        `json:"filters"`
+	QueryTag         string         `json:"querytag"`
+	WatchTag         string         `json:"watchtag"`
+	TCPTag           string         `json:"tcptag"`
+	Panels           []Panel        `json:"panels"`
+	SafeMode         bool           `json:"safemode"`
+	SafeAPIErrors    int            `json:"safeapierrors"`
+	SafeNodeFails    int            `json:"safenodefails"`
+	SafeWindow       int            `json:"safewindow"`
+	DebugLevel       int            `json:"debug"`
+	ReloadTime       int            `json:"reloadtime"`
+	DefEnable        bool           `json:"defenable"`
+	DefScanTime      int            `json:"defscantime"`
+	DefMaxFails      int            `json:"defmaxfails"`
+	DefMaxRestarts   int            `json:"defmaxrestarts"`
+	DefRestartInt    int            `json:"defrestartint"`
+	DefReportOnly    bool           `json:"defreportonly"`
+	DefA2STimeout    int            `json:"defa2stimeout"`
+	DefMentions      string         `json:"defmentions"`
+	DefRestartMode   string         `json:"defrestartmode"`
+	DefStopTimeout   int            `json:"defstoptimeout"`
+	DefStartTimeout  int            `json:"defstarttimeout"`
+	DefVerifyTimeout int            `json:"defverifytimeout"`
+	DefCommands      []Command      `json:"defcommands"`
+	DefBackup        bool           `json:"defbackup"`
+	DefBackupTimeout int            `json:"defbackuptimeout"`
+	DefSchedule      string         `json:"defschedule"`
+	DefSchedTimeout  int            `json:"defscheduletimeout"`
+	DefLogFile       string         `json:"deflogfile"`
+	DefLogLines      int            `json:"defloglines"`
+	DefRules         []ResourceRule `json:"defrules"`
+	DefKeepRunning   bool           `json:"defkeeprunning"`
+	DefStopMarker    string         `json:"defstopmarker"`
+	DefMaxStarting   int            `json:"defmaxstarting"`
+	DefMaxStopping   int            `json:"defmaxstopping"`
+	DefWarmUp        int            `json:"defwarmup"`
+	DefWarmUpEarly   bool           `json:"defwarmupearly"`
+	DefGiveUpAction  string         `json:"defgiveupaction"`
+	DefGiveUpReset   int            `json:"defgiveupreset"`
+	DefResetMarker   string         `json:"defresetmarker"`
+	DefNotifyInstall bool           `json:"defnotifyinstall"`
+	Servers          []Server       `json:"servers"`
+	Misc             []Misc         `json:"misc"`
+	ConfLoc          string
 }
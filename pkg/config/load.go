@@ -27,13 +27,32 @@ func (cfg *Config) ReadConfig(path string) error {
 
 	err = json.Unmarshal([]byte(data), cfg)
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	err = cfg.ValidatePanels()
+
+	if err != nil {
+		return err
+	}
+
+	return cfg.ValidateServers()
 }
 
 // Sets config's default values.
 func (cfg *Config) SetDefaults() {
 	// Set config defaults.
 	cfg.AddServers = false
+	cfg.PanelType = "pterodactyl"
+	cfg.Discovery = "application"
+	cfg.QueryTag = "pterowatch:query"
+	cfg.WatchTag = "pterowatch:watch"
+	cfg.TCPTag = "pterowatch:tcp"
+	cfg.SafeMode = true
+	cfg.SafeAPIErrors = 3
+	cfg.SafeNodeFails = 3
+	cfg.SafeWindow = 60
 	cfg.DebugLevel = 0
 	cfg.ReloadTime = 500
 
@@ -44,4 +63,24 @@ func (cfg *Config) SetDefaults() {
 	cfg.DefRestartInt = 120
 	cfg.DefReportOnly = false
 	cfg.DefA2STimeout = 1
+	cfg.DefRestartMode = "kill"
+	cfg.DefStopTimeout = 30
+	cfg.DefStartTimeout = 120
+	cfg.DefVerifyTimeout = 300
+	cfg.DefBackup = false
+	cfg.DefBackupTimeout = 600
+	cfg.DefSchedule = ""
+	cfg.DefSchedTimeout = 300
+	cfg.DefLogFile = ""
+	cfg.DefLogLines = 20
+	cfg.DefKeepRunning = false
+	cfg.DefStopMarker = ""
+	cfg.DefMaxStarting = 0
+	cfg.DefMaxStopping = 0
+	cfg.DefWarmUp = 0
+	cfg.DefWarmUpEarly = false
+	cfg.DefGiveUpAction = "none"
+	cfg.DefGiveUpReset = 0
+	cfg.DefResetMarker = ""
+	cfg.DefNotifyInstall = false
 }
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// The name of the panel built from the top-level API settings.
+const DefPanelName = "default"
+
+// Supported panel types. Panels without a type are Pterodactyl panels.
+var PanelTypes = []string{"pterodactyl", "pelican"}
+
+// Retrieves all panels. If the top-level API URL is set, it's included as the "default" panel for older configs.
+func (cfg *Config) GetPanels() []Panel {
+	panels := []Panel{}
+
+	if len(cfg.APIURL) > 0 {
+		panels = append(panels, Panel{
+			Name:       DefPanelName,
+			Type:       cfg.PanelType,
+			APIURL:     cfg.APIURL,
+			Token:      cfg.Token,
+			AppToken:   cfg.AppToken,
+			AddServers: cfg.AddServers,
+			Discovery:  cfg.Discovery,
+			Filters:    cfg.Filters,
+		})
+	}
+
+	panels = append(panels, cfg.Panels...)
+
+	return panels
+}
+
+// Makes sure each panel's type is supported.
+func (cfg *Config) ValidatePanels() error {
+	for _, panel := range cfg.GetPanels() {
+		if len(panel.Type) < 1 {
+			continue
+		}
+
+		valid := false
+
+		for _, t := range PanelTypes {
+			if panel.Type == t {
+				valid = true
+			}
+		}
+
+		if !valid {
+			return errors.New("panel '" + panel.Name + "' has an unknown paneltype '" + panel.Type + "' (expected " + strings.Join(PanelTypes, " or ") + ")")
+		}
+	}
+
+	return nil
+}
+
+// Retrieves a panel by name. If the name is empty, the first panel is returned. Returns nil if the panel isn't found.
+func (cfg *Config) GetPanel(name string) *Panel {
+	panels := cfg.GetPanels()
+
+	for i := range panels {
+		if len(name) < 1 || panels[i].Name == name {
+			return &panels[i]
+		}
+	}
+
+	return nil
+}
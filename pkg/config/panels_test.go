@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatePanels(t *testing.T) {
+	valid := []string{"", "pterodactyl", "pelican"}
+
+	for _, paneltype := range valid {
+		cfg := Config{APIURL: "http://panel/", PanelType: paneltype}
+
+		if err := cfg.ValidatePanels(); err != nil {
+			t.Errorf("paneltype %q rejected: %v", paneltype, err)
+		}
+	}
+
+	invalid := []string{"Pelican", "pterodatcyl", "wings"}
+
+	for _, paneltype := range invalid {
+		cfg := Config{Panels: []Panel{{Name: "second", Type: paneltype}}}
+
+		if err := cfg.ValidatePanels(); err == nil {
+			t.Errorf("paneltype %q accepted", paneltype)
+		}
+	}
+}
+
+func TestReadConfigRejectsPanelType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pterowatch")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "pterowatch.conf")
+
+	err = ioutil.WriteFile(path, []byte(`{"apiurl": "http://panel/", "paneltype": "Pelican"}`), 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{}
+	cfg.SetDefaults()
+
+	if err := cfg.ReadConfig(path); err == nil {
+		t.Error("config with unknown paneltype was read")
+	}
+}
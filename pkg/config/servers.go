@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Supported resource rule resources, operators, and actions. Rules without an operator or action use ">" and "fail".
+var (
+	RuleResources = []string{"cpu", "memory", "disk", "netrx", "nettx"}
+	RuleOps       = []string{">", ">=", "<", "<=", "=="}
+	RuleActions   = []string{"warn", "fail"}
+)
+
+// Supported protocols of extra addresses. Addresses without a protocol use "a2s".
+var AddressProtos = []string{"a2s", "tcp"}
+
+// Checks whether a list includes a string.
+func contains(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validates resource rules.
+func ValidateRules(rules []ResourceRule) error {
+	for i, rule := range rules {
+		if !contains(RuleResources, rule.Resource) {
+			return errors.New("rule #" + strconv.Itoa(i) + " has an invalid resource '" + rule.Resource + "'")
+		}
+
+		if len(rule.Op) > 0 && !contains(RuleOps, rule.Op) {
+			return errors.New("rule #" + strconv.Itoa(i) + " has an invalid operator '" + rule.Op + "'")
+		}
+
+		if len(rule.Action) > 0 && !contains(RuleActions, rule.Action) {
+			return errors.New("rule #" + strconv.Itoa(i) + " has an invalid action '" + rule.Action + "'")
+		}
+
+		if rule.Duration < 0 {
+			return errors.New("rule #" + strconv.Itoa(i) + " has a negative duration")
+		}
+	}
+
+	return nil
+}
+
+// Makes sure the default server values and each server from the config file are valid (e.g. their resource rules and extra addresses).
+func (cfg *Config) ValidateServers() error {
+	if err := ValidateRules(cfg.DefRules); err != nil {
+		return errors.New("defrules: " + err.Error())
+	}
+
+	if cfg.DefRestartMode == "schedule" && len(cfg.DefSchedule) < 1 {
+		return errors.New("defrestartmode: the schedule restart mode requires defschedule")
+	}
+
+	for _, srv := range cfg.Servers {
+		if err := ValidateRules(srv.Rules); err != nil {
+			return errors.New("server '" + srv.Name + "' (" + srv.UID + "): " + err.Error())
+		}
+
+		if srv.RestartMode == "schedule" && len(srv.Schedule) < 1 {
+			return errors.New("server '" + srv.Name + "' (" + srv.UID + "): the schedule restart mode requires a schedule")
+		}
+
+		for _, addr := range srv.Extra {
+			if len(addr.Proto) > 0 && !contains(AddressProtos, addr.Proto) {
+				return errors.New("server '" + srv.Name + "' (" + srv.UID + "): extra address " + addr.IP + ":" + strconv.Itoa(addr.Port) + " has an invalid proto '" + addr.Proto + "'")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateRules(t *testing.T) {
+	valid := []ResourceRule{
+		{Resource: "cpu", Value: 90},
+		{Resource: "memory", Op: ">=", Value: 95, Percent: true, Duration: 60, Action: "warn"},
+		{Resource: "nettx", Op: "<", Value: 1, Action: "fail"},
+	}
+
+	if err := ValidateRules(valid); err != nil {
+		t.Errorf("valid rules rejected: %v", err)
+	}
+
+	invalid := []ResourceRule{
+		{Resource: "mem"},
+		{Resource: "cpu", Op: "=>"},
+		{Resource: "cpu", Action: "restart"},
+		{Resource: "cpu", Duration: -1},
+	}
+
+	for _, rule := range invalid {
+		if err := ValidateRules([]ResourceRule{rule}); err == nil {
+			t.Errorf("rule %+v accepted", rule)
+		}
+	}
+}
+
+func TestReadConfigRejectsRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pterowatch")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	configs := []string{
+		`{"servers": [{"name": "Rust", "uid": "1a7ce997", "rules": [{"resource": "mem", "value": 90}]}]}`,
+		`{"defrules": [{"resource": "cpu", "op": "=>", "value": 90}]}`,
+	}
+
+	for _, data := range configs {
+		path := filepath.Join(dir, "pterowatch.conf")
+
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := Config{}
+		cfg.SetDefaults()
+
+		if err := cfg.ReadConfig(path); err == nil {
+			t.Errorf("config with invalid rule was read: %s", data)
+		}
+	}
+}
+
+func TestValidateServersExtraProto(t *testing.T) {
+	cfg := Config{Servers: []Server{{Name: "Rust", Extra: []Address{{IP: "192.0.2.10", Port: 28017}, {IP: "192.0.2.10", Port: 28016, Proto: "tcp"}}}}}
+
+	if err := cfg.ValidateServers(); err != nil {
+		t.Errorf("valid extra addresses rejected: %v", err)
+	}
+
+	cfg.Servers[0].Extra[1].Proto = "rcon"
+
+	if err := cfg.ValidateServers(); err == nil {
+		t.Error("extra address with unknown proto accepted")
+	}
+}
+
+func TestValidateServersSchedule(t *testing.T) {
+	cfg := Config{Servers: []Server{{Name: "Rust", RestartMode: "schedule", Schedule: "Restart"}}}
+
+	if err := cfg.ValidateServers(); err != nil {
+		t.Errorf("schedule restart mode with a schedule rejected: %v", err)
+	}
+
+	cfg.Servers[0].Schedule = ""
+
+	if err := cfg.ValidateServers(); err == nil {
+		t.Error("schedule restart mode without a schedule accepted")
+	}
+
+	cfg = Config{DefRestartMode: "schedule"}
+
+	if err := cfg.ValidateServers(); err == nil {
+		t.Error("default schedule restart mode without a default schedule accepted")
+	}
+}